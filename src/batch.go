@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// batchMaxFiles caps how many files go into one p4 invocation
+	batchMaxFiles = 500
+	// batchMaxBytes caps the total path length of a chunk, kept well under the
+	// 32,767 character Windows command-line limit
+	batchMaxBytes = 30000
+)
+
+// BatchFileResult is the outcome of a batched command for one file
+type BatchFileResult struct {
+	File    string
+	OK      bool
+	Warning bool // p4 only warned (e.g. "file(s) not opened on this client"); not OK either
+	Message string
+}
+
+// BatchResult aggregates the per-file outcomes of a batched command
+type BatchResult struct {
+	Command string
+	Files   []BatchFileResult
+}
+
+// Failed returns the files that could not be processed
+func (r *BatchResult) Failed() []BatchFileResult {
+	var failed []BatchFileResult
+	for _, file := range r.Files {
+		if !file.OK {
+			failed = append(failed, file)
+		}
+	}
+	return failed
+}
+
+// ============================================================================
+// EXECUTION
+// ============================================================================
+
+// runP4Batch runs "p4 -s -x - <args>" over the files in chunks, passing the
+// file list as an argument file on stdin, and collects a result per file
+func runP4Batch(ctx context.Context, files []string, args ...string) *BatchResult {
+	result := &BatchResult{Command: "p4 " + strings.Join(args, " ")}
+	chunks := chunkFiles(files, batchMaxFiles, batchMaxBytes)
+
+	for i, chunk := range chunks {
+		if ctx.Err() != nil {
+			for _, file := range chunk {
+				result.Files = append(result.Files, BatchFileResult{File: file, Message: errCancelled.Error()})
+			}
+			continue
+		}
+
+		fmt.Printf("  [%d/%d] %s (%d file(s))...\n", i+1, len(chunks), result.Command, len(chunk))
+
+		stdin := strings.Join(chunk, "\n") + "\n"
+		output, err := p4Runner.Run(ctx, P4Command{
+			Args:  append([]string{"-s", "-x", "-"}, args...),
+			Stdin: stdin,
+		})
+		result.Files = append(result.Files, parseBatchOutput(ctx, chunk, output.Combined(), err)...)
+	}
+
+	return result
+}
+
+// chunkFiles splits files into chunks limited by count and total path length
+func chunkFiles(files []string, maxFiles int, maxBytes int) [][]string {
+	var chunks [][]string
+	var current []string
+	size := 0
+
+	for _, file := range files {
+		if len(current) > 0 && (len(current) >= maxFiles || size+len(file)+1 > maxBytes) {
+			chunks = append(chunks, current)
+			current = nil
+			size = 0
+		}
+		current = append(current, file)
+		size += len(file) + 1
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+// parseBatchOutput matches "p4 -s" output lines ("info: ...", "warning: ...",
+// "error: ...") back to the files of a chunk. Warnings such as "no such
+// file(s)" mean nothing was done to the file, so they are not OK either.
+func parseBatchOutput(ctx context.Context, chunk []string, output []byte, runErr error) []BatchFileResult {
+	results := make([]BatchFileResult, len(chunk))
+	seen := make([]bool, len(chunk))
+	for i, file := range chunk {
+		results[i] = BatchFileResult{File: file, OK: true}
+	}
+
+	lookup := make(map[string]int)
+	for i, file := range chunk {
+		lookup[batchPathKey(file)] = i
+	}
+
+	var unmatchedErrors []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		level, message, found := strings.Cut(line, ": ")
+		if !found || level == "exit" {
+			continue
+		}
+		isError := level == "error"
+		isWarning := level == "warning"
+
+		index, ok := matchBatchLine(ctx, message, lookup)
+		if !ok {
+			if isError {
+				unmatchedErrors = append(unmatchedErrors, message)
+			}
+			continue
+		}
+
+		if !seen[index] || isError || (isWarning && results[index].OK) {
+			results[index].Message = batchMessageText(message)
+		}
+		if isError {
+			results[index].OK = false
+			results[index].Warning = false
+		} else if isWarning && results[index].OK {
+			results[index].OK = false
+			results[index].Warning = true
+		}
+		seen[index] = true
+	}
+
+	// Errors that name no file (bad connection, bad flags) apply to the files
+	// we heard nothing about
+	if len(unmatchedErrors) > 0 || (runErr != nil && len(output) == 0) {
+		reason := strings.Join(unmatchedErrors, "; ")
+		if reason == "" {
+			reason = runErr.Error()
+		}
+		for i := range results {
+			if !seen[i] {
+				results[i].OK = false
+				results[i].Message = reason
+			}
+		}
+	}
+
+	return results
+}
+
+// matchBatchLine finds which file of the chunk a message refers to
+func matchBatchLine(ctx context.Context, message string, lookup map[string]int) (int, bool) {
+	path, _, found := strings.Cut(message, " - ")
+	if !found {
+		return 0, false
+	}
+	if hash := strings.Index(path, "#"); hash >= 0 {
+		path = path[:hash]
+	}
+
+	if index, ok := lookup[batchPathKey(path)]; ok {
+		return index, true
+	}
+
+	// p4 often answers with the depot path of a file given in local syntax
+	if view, err := getClientView(ctx); err == nil {
+		if localPath, ok := view.DepotToLocal(path); ok {
+			if index, ok := lookup[batchPathKey(localPath)]; ok {
+				return index, true
+			}
+		}
+		if depotPath, ok := view.LocalToDepot(path); ok {
+			if index, ok := lookup[batchPathKey(depotPath)]; ok {
+				return index, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// batchPathKey normalises a path for matching output lines to files
+func batchPathKey(path string) string {
+	if strings.HasPrefix(path, "//") {
+		return path
+	}
+	path = filepath.Clean(path)
+	if runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// batchMessageText strips the file path from a message, keeping the reason
+func batchMessageText(message string) string {
+	if _, text, found := strings.Cut(message, " - "); found {
+		return text
+	}
+	return message
+}
+
+// ============================================================================
+// DISPLAY
+// ============================================================================
+
+// printBatchSummary prints a summary table of a batched command
+func printBatchSummary(result *BatchResult) {
+	failed := result.Failed()
+	succeeded := len(result.Files) - len(failed)
+	warnings := 0
+	for _, file := range failed {
+		if file.Warning {
+			warnings++
+		}
+	}
+
+	fmt.Println("\n─────────────────────────────────────")
+	fmt.Printf("Summary: %s\n", result.Command)
+	fmt.Printf("  ✓ Succeeded: %d\n", succeeded)
+	fmt.Printf("  ✗ Failed:    %d\n", len(failed)-warnings)
+	if warnings > 0 {
+		fmt.Printf("  ⚠ Skipped:   %d (p4 warning, nothing done)\n", warnings)
+	}
+
+	if len(failed) == 0 {
+		return
+	}
+
+	width := 0
+	for _, file := range failed {
+		if len(file.File) > width {
+			width = len(file.File)
+		}
+	}
+	if width > 60 {
+		width = 60
+	}
+
+	fmt.Println()
+	fmt.Printf("  %-*s  %s\n", width, "FILE", "REASON")
+	fmt.Printf("  %-*s  %s\n", width, strings.Repeat("─", width), strings.Repeat("─", 30))
+	for _, file := range failed {
+		reason := file.Message
+		if file.Warning {
+			reason = "⚠ " + reason
+		}
+		fmt.Printf("  %-*s  %s\n", width, truncateLeft(file.File, width), reason)
+	}
+}
+
+// truncateLeft shortens a path from the left so its file name stays visible
+func truncateLeft(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return "..." + s[len(s)-max+3:]
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestChunkFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		maxFiles int
+		maxBytes int
+		want     [][]string
+	}{
+		{"empty", nil, 2, 100, nil},
+		{"fits in one chunk", []string{"a", "b"}, 5, 100, [][]string{{"a", "b"}}},
+		{"split by count", []string{"a", "b", "c"}, 2, 100, [][]string{{"a", "b"}, {"c"}}},
+		{"split by bytes", []string{"aaaa", "bbbb", "cc"}, 10, 10, [][]string{{"aaaa", "bbbb"}, {"cc"}}},
+		{"oversized path gets its own chunk", []string{"a", "bbbbbbbbbbbb", "c"}, 10, 5, [][]string{{"a"}, {"bbbbbbbbbbbb"}, {"c"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chunkFiles(tt.files, tt.maxFiles, tt.maxBytes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunkFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBatchOutput(t *testing.T) {
+	chunk := []string{"//depot/a.txt", "//depot/b.txt", "//depot/c.txt"}
+
+	tests := []struct {
+		name   string
+		output string
+		runErr error
+		want   []BatchFileResult
+	}{
+		{
+			name:   "all succeed",
+			output: "info: //depot/a.txt#3 - was edit, reverted\ninfo: //depot/b.txt#1 - was edit, reverted\ninfo: //depot/c.txt#2 - was edit, reverted\nexit: 0\n",
+			want: []BatchFileResult{
+				{File: "//depot/a.txt", OK: true, Message: "was edit, reverted"},
+				{File: "//depot/b.txt", OK: true, Message: "was edit, reverted"},
+				{File: "//depot/c.txt", OK: true, Message: "was edit, reverted"},
+			},
+		},
+		{
+			name:   "error for one file",
+			output: "info: //depot/a.txt#3 - opened for edit\nerror: //depot/b.txt - file(s) locked by another user\ninfo: //depot/c.txt#2 - opened for edit\n",
+			runErr: errors.New("exit status 1"),
+			want: []BatchFileResult{
+				{File: "//depot/a.txt", OK: true, Message: "opened for edit"},
+				{File: "//depot/b.txt", OK: false, Message: "file(s) locked by another user"},
+				{File: "//depot/c.txt", OK: true, Message: "opened for edit"},
+			},
+		},
+		{
+			name:   "warnings are not successes",
+			output: "info: //depot/a.txt#3 - was edit, reverted\nwarning: //depot/b.txt - file(s) not opened on this client.\nwarning: //depot/c.txt - no such file(s).\n",
+			want: []BatchFileResult{
+				{File: "//depot/a.txt", OK: true, Message: "was edit, reverted"},
+				{File: "//depot/b.txt", OK: false, Warning: true, Message: "file(s) not opened on this client."},
+				{File: "//depot/c.txt", OK: false, Warning: true, Message: "no such file(s)."},
+			},
+		},
+		{
+			name:   "error after a warning wins",
+			output: "warning: //depot/a.txt - no such file(s).\nerror: //depot/a.txt - access denied\ninfo: //depot/b.txt - ok\ninfo: //depot/c.txt - ok\n",
+			want: []BatchFileResult{
+				{File: "//depot/a.txt", OK: false, Message: "access denied"},
+				{File: "//depot/b.txt", OK: true, Message: "ok"},
+				{File: "//depot/c.txt", OK: true, Message: "ok"},
+			},
+		},
+		{
+			name:   "error naming no file fails unreported files",
+			output: "info: //depot/a.txt#3 - opened for edit\nerror: Perforce password (P4PASSWD) invalid or unset.\n",
+			runErr: errors.New("exit status 1"),
+			want: []BatchFileResult{
+				{File: "//depot/a.txt", OK: true, Message: "opened for edit"},
+				{File: "//depot/b.txt", OK: false, Message: "Perforce password (P4PASSWD) invalid or unset."},
+				{File: "//depot/c.txt", OK: false, Message: "Perforce password (P4PASSWD) invalid or unset."},
+			},
+		},
+		{
+			name:   "failed run without output",
+			runErr: errors.New("p4 not found"),
+			want: []BatchFileResult{
+				{File: "//depot/a.txt", OK: false, Message: "p4 not found"},
+				{File: "//depot/b.txt", OK: false, Message: "p4 not found"},
+				{File: "//depot/c.txt", OK: false, Message: "p4 not found"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseBatchOutput(context.Background(), chunk, []byte(tt.output), tt.runErr)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBatchOutput() =\n  %+v\nwant\n  %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunP4BatchUsesArgumentFile(t *testing.T) {
+	fake := NewFakeP4Runner()
+	fake.On("-s -x - revert -a", FakeResponse{Stdout: "info: //depot/a.txt#1 - was edit, reverted\nwarning: //depot/b.txt - file(s) not opened on this client.\n"})
+	defer setP4Runner(setP4Runner(fake))
+
+	result := runP4Batch(context.Background(), []string{"//depot/a.txt", "//depot/b.txt"}, "revert", "-a")
+
+	if len(fake.Calls) != 1 || fake.Calls[0].Stdin != "//depot/a.txt\n//depot/b.txt\n" {
+		t.Fatalf("calls = %+v, want one call with both files on stdin", fake.Calls)
+	}
+	failed := result.Failed()
+	if len(failed) != 1 || failed[0].File != "//depot/b.txt" || !failed[0].Warning {
+		t.Errorf("Failed() = %+v, want only //depot/b.txt as a warning", failed)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// errCancelled is returned by p4 calls interrupted with Ctrl+C
+var errCancelled = errors.New("cancelled by user")
+
+// errTimedOut is wrapped by errors from p4 calls that hit the configured timeout
+var errTimedOut = errors.New("timed out")
+
+// currentOperation holds the cancel function of the operation Ctrl+C should stop
+var currentOperation struct {
+	sync.Mutex
+	cancel context.CancelFunc
+}
+
+// installInterruptHandler makes Ctrl+C cancel the running operation instead
+// of killing the program
+func installInterruptHandler() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+
+	go func() {
+		for range signals {
+			currentOperation.Lock()
+			cancel := currentOperation.cancel
+			currentOperation.Unlock()
+
+			if cancel != nil {
+				fmt.Println("\n⚠ Cancelling current operation...")
+				cancel()
+			} else {
+				fmt.Println("\n(Ctrl+C cancels running operations - use the menu to exit)")
+			}
+		}
+	}()
+}
+
+// beginOperation starts an operation that Ctrl+C can cancel.
+// Call the returned function when the operation is over.
+func beginOperation() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	currentOperation.Lock()
+	currentOperation.cancel = cancel
+	currentOperation.Unlock()
+
+	return ctx, func() {
+		currentOperation.Lock()
+		currentOperation.cancel = nil
+		currentOperation.Unlock()
+		cancel()
+	}
+}
+
+// isCancelled reports whether err (or ctx) means the user pressed Ctrl+C
+func isCancelled(ctx context.Context, err error) bool {
+	return errors.Is(err, errCancelled) || errors.Is(err, context.Canceled) || ctx.Err() != nil
+}
+
+// isInterrupted reports whether a p4 call was cut short by Ctrl+C or a timeout,
+// meaning its output is incomplete
+func isInterrupted(ctx context.Context, err error) bool {
+	return isCancelled(ctx, err) || errors.Is(err, errTimedOut)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestScanForModifiedFilesScopedCancelled(t *testing.T) {
+	_, fake := setupFakeWorkspace(t)
+	fake.On("-ztag diff -se", FakeResponse{Stdout: "... depotFile //depot/Game/a.txt\n\n"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := ScanForModifiedFilesScoped(ctx, nil, "", false, true, true, false, false, false)
+	if err != nil {
+		t.Fatalf("ScanForModifiedFilesScoped() error = %v, want partial results", err)
+	}
+	if !result.Cancelled {
+		t.Error("Cancelled = false, want true")
+	}
+	if fake.CallCount("-ztag diff -sr") != 0 {
+		t.Error("kept scanning after the scan was cancelled")
+	}
+}
+
+func TestRunP4BatchCancelled(t *testing.T) {
+	_, fake := setupFakeWorkspace(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := runP4Batch(ctx, []string{"//depot/Game/a.txt", "//depot/Game/b.txt"}, "edit")
+	if len(fake.Calls) != 0 || len(result.Failed()) != 2 {
+		t.Errorf("cancelled batch ran %d call(s) and failed %d file(s), want 0 and 2", len(fake.Calls), len(result.Failed()))
+	}
+}
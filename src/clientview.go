@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ClientView translates paths between depot, client and local syntax using
+// the workspace View from "p4 client -o", so no server round trip is needed
+type ClientView struct {
+	ClientName    string
+	ServerAddr    string
+	Root          string
+	AltRoots      []string
+	CaseSensitive bool
+	mappings      []viewMapping
+}
+
+// viewMapping is one line of the client View
+type viewMapping struct {
+	mode   byte // 0 for a normal mapping, '+' overlay, '-' exclusion, '&' ditto
+	depot  *viewPattern
+	client *viewPattern
+}
+
+// viewPattern is one side of a mapping compiled into a regular expression.
+// Wildcards are captured in the order they appear in the pattern.
+type viewPattern struct {
+	raw   string
+	regex *regexp.Regexp
+	parts []patternPart
+}
+
+// patternPart is either a literal run of text or a wildcard
+type patternPart struct {
+	literal  string
+	wildcard string // "...", "*" or "%%"
+	position int    // n for %%n
+}
+
+// sessionClientView is loaded once per session by getClientView
+var sessionClientView struct {
+	sync.Mutex
+	view   *ClientView
+	err    error
+	loaded bool
+}
+
+// getClientView returns the current workspace's view, loading it on first use
+func getClientView(ctx context.Context) (*ClientView, error) {
+	sessionClientView.Lock()
+	defer sessionClientView.Unlock()
+
+	if !sessionClientView.loaded {
+		view, err := loadClientView(ctx)
+		if err != nil && isInterrupted(ctx, err) {
+			// Try again next time rather than remembering an interrupted load
+			return nil, err
+		}
+		sessionClientView.view, sessionClientView.err = view, err
+		sessionClientView.loaded = true
+	}
+	return sessionClientView.view, sessionClientView.err
+}
+
+// loadClientView reads the client spec and the server's case handling
+func loadClientView(ctx context.Context) (*ClientView, error) {
+	info, err := p4InfoRecord(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server info: %w", err)
+	}
+
+	records, err := runP4Tagged(ctx, "client", "-o")
+	if err != nil || len(records) == 0 {
+		return nil, fmt.Errorf("failed to read client spec: %w", err)
+	}
+
+	view, err := parseClientView(records[0], info["caseHandling"] == "sensitive")
+	if view != nil {
+		view.ServerAddr = info["serverAddress"]
+	}
+	return view, err
+}
+
+// parseClientView builds a ClientView from a tagged "p4 client -o" record
+func parseClientView(spec P4Record, caseSensitive bool) (*ClientView, error) {
+	view := &ClientView{
+		ClientName:    spec["Client"],
+		Root:          spec["Root"],
+		CaseSensitive: caseSensitive,
+	}
+
+	for i := 0; ; i++ {
+		altRoot, ok := spec[fmt.Sprintf("AltRoots%d", i)]
+		if !ok {
+			break
+		}
+		view.AltRoots = append(view.AltRoots, altRoot)
+	}
+
+	for i := 0; ; i++ {
+		line, ok := spec[fmt.Sprintf("View%d", i)]
+		if !ok {
+			break
+		}
+		mapping, err := parseViewLine(line, caseSensitive)
+		if err != nil {
+			return nil, fmt.Errorf("View%d: %v", i, err)
+		}
+		view.mappings = append(view.mappings, mapping)
+	}
+
+	if view.ClientName == "" {
+		return nil, fmt.Errorf("client spec has no Client field")
+	}
+
+	return view, nil
+}
+
+// parseViewLine parses a line such as `-"//depot/a b/..." //ws/a/...`
+func parseViewLine(line string, caseSensitive bool) (viewMapping, error) {
+	fields := splitViewFields(line)
+	if len(fields) != 2 {
+		return viewMapping{}, fmt.Errorf("expected depot and client paths in %q", line)
+	}
+
+	var mapping viewMapping
+	depotSide := fields[0]
+	if depotSide != "" && strings.ContainsRune("+-&", rune(depotSide[0])) {
+		mapping.mode = depotSide[0]
+		depotSide = depotSide[1:]
+	}
+
+	var err error
+	mapping.depot, err = compileViewPattern(depotSide, caseSensitive)
+	if err != nil {
+		return viewMapping{}, err
+	}
+	mapping.client, err = compileViewPattern(fields[1], caseSensitive)
+	if err != nil {
+		return viewMapping{}, err
+	}
+
+	return mapping, nil
+}
+
+// splitViewFields splits a View line on whitespace, honouring double quotes.
+// A leading +, - or & outside the quotes is kept with the path.
+func splitViewFields(line string) []string {
+	var fields []string
+	var current strings.Builder
+	inQuotes := false
+	hasField := false
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasField = true
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if hasField {
+				fields = append(fields, current.String())
+				current.Reset()
+				hasField = false
+			}
+		default:
+			current.WriteRune(r)
+			hasField = true
+		}
+	}
+	if hasField {
+		fields = append(fields, current.String())
+	}
+
+	return fields
+}
+
+// compileViewPattern turns a view path with ..., * and %%n into a regex
+func compileViewPattern(raw string, caseSensitive bool) (*viewPattern, error) {
+	pattern := &viewPattern{raw: raw}
+	var expr strings.Builder
+	var literal strings.Builder
+
+	flushLiteral := func() {
+		if literal.Len() > 0 {
+			pattern.parts = append(pattern.parts, patternPart{literal: literal.String()})
+			expr.WriteString(regexp.QuoteMeta(literal.String()))
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(raw); {
+		switch {
+		case strings.HasPrefix(raw[i:], "..."):
+			flushLiteral()
+			pattern.parts = append(pattern.parts, patternPart{wildcard: "..."})
+			expr.WriteString("(.*)")
+			i += 3
+		case raw[i] == '*':
+			flushLiteral()
+			pattern.parts = append(pattern.parts, patternPart{wildcard: "*"})
+			expr.WriteString("([^/]*)")
+			i++
+		case strings.HasPrefix(raw[i:], "%%") && i+2 < len(raw) && raw[i+2] >= '0' && raw[i+2] <= '9':
+			flushLiteral()
+			pattern.parts = append(pattern.parts, patternPart{wildcard: "%%", position: int(raw[i+2] - '0')})
+			expr.WriteString("([^/]*)")
+			i += 3
+		default:
+			literal.WriteByte(raw[i])
+			i++
+		}
+	}
+	flushLiteral()
+
+	prefix := "^"
+	if !caseSensitive {
+		prefix = "(?i)^"
+	}
+
+	regex, err := regexp.Compile(prefix + expr.String() + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid view path %q: %v", raw, err)
+	}
+	pattern.regex = regex
+
+	return pattern, nil
+}
+
+// match returns the captured wildcard values, or nil if the path does not match
+func (p *viewPattern) match(path string) []string {
+	groups := p.regex.FindStringSubmatch(path)
+	if groups == nil {
+		return nil
+	}
+	return groups[1:]
+}
+
+// expand fills this pattern's wildcards with values captured by the source pattern.
+// "..." and "*" pair up by order of appearance, %%n pairs up by number.
+func (p *viewPattern) expand(source *viewPattern, values []string) string {
+	byKind := make(map[string][]string)
+	byPosition := make(map[int]string)
+	index := 0
+	for _, part := range source.parts {
+		if part.wildcard == "" {
+			continue
+		}
+		if part.wildcard == "%%" {
+			byPosition[part.position] = values[index]
+		} else {
+			byKind[part.wildcard] = append(byKind[part.wildcard], values[index])
+		}
+		index++
+	}
+
+	var result strings.Builder
+	used := make(map[string]int)
+	for _, part := range p.parts {
+		switch part.wildcard {
+		case "":
+			result.WriteString(part.literal)
+		case "%%":
+			result.WriteString(byPosition[part.position])
+		default:
+			if n := used[part.wildcard]; n < len(byKind[part.wildcard]) {
+				result.WriteString(byKind[part.wildcard][n])
+			}
+			used[part.wildcard]++
+		}
+	}
+
+	return result.String()
+}
+
+// ============================================================================
+// TRANSLATION
+// ============================================================================
+
+// DepotToClient maps a depot path to client syntax (//client/...)
+func (v *ClientView) DepotToClient(depotPath string) (string, bool) {
+	return v.translate(depotPath, true)
+}
+
+// ClientToDepot maps a client syntax path back to its depot path
+func (v *ClientView) ClientToDepot(clientPath string) (string, bool) {
+	return v.translate(clientPath, false)
+}
+
+// translate applies the view in either direction. The last matching line wins;
+// an exclusion unmaps the path, and a later non-overlay line that claims the
+// translated path on the other side hides the earlier mapping.
+func (v *ClientView) translate(path string, fromDepot bool) (string, bool) {
+	for i := len(v.mappings) - 1; i >= 0; i-- {
+		mapping := v.mappings[i]
+		source, target := mapping.depot, mapping.client
+		if !fromDepot {
+			source, target = mapping.client, mapping.depot
+		}
+
+		values := source.match(path)
+		if values == nil {
+			continue
+		}
+		if mapping.mode == '-' {
+			return "", false
+		}
+
+		translated := target.expand(source, values)
+		for _, later := range v.mappings[i+1:] {
+			if later.mode == '+' || later.mode == '&' {
+				continue
+			}
+			laterTarget := later.client
+			if !fromDepot {
+				laterTarget = later.depot
+			}
+			if laterTarget.match(translated) != nil {
+				return "", false
+			}
+		}
+
+		return translated, true
+	}
+
+	return "", false
+}
+
+// ClientToLocal converts //client/path into a path under the workspace root
+func (v *ClientView) ClientToLocal(clientPath string) (string, bool) {
+	prefix := "//" + v.ClientName + "/"
+	if v.Root == "" || strings.EqualFold(v.Root, "null") || !v.hasPrefix(clientPath, prefix) {
+		return "", false
+	}
+
+	relative := unescapeP4Path(clientPath[len(prefix):])
+	return filepath.Join(v.Root, filepath.FromSlash(relative)), true
+}
+
+// LocalToClient converts a local path under the root (or an AltRoot) into client syntax
+func (v *ClientView) LocalToClient(localPath string) (string, bool) {
+	localPath = filepath.Clean(localPath)
+
+	for _, root := range append([]string{v.Root}, v.AltRoots...) {
+		if root == "" || strings.EqualFold(root, "null") {
+			continue
+		}
+		root = filepath.Clean(root)
+		if !pathHasPrefix(localPath, root, v.CaseSensitive) {
+			continue
+		}
+		relative := strings.TrimLeft(localPath[len(root):], `\/`)
+		return "//" + v.ClientName + "/" + escapeP4Path(filepath.ToSlash(relative)), true
+	}
+
+	return "", false
+}
+
+// DepotToLocal maps a depot path straight to a local path
+func (v *ClientView) DepotToLocal(depotPath string) (string, bool) {
+	clientPath, ok := v.DepotToClient(depotPath)
+	if !ok {
+		return "", false
+	}
+	return v.ClientToLocal(clientPath)
+}
+
+// LocalToDepot maps a local path straight to its depot path
+func (v *ClientView) LocalToDepot(localPath string) (string, bool) {
+	clientPath, ok := v.LocalToClient(localPath)
+	if !ok {
+		return "", false
+	}
+	return v.ClientToDepot(clientPath)
+}
+
+// hasPrefix compares a prefix using the server's case handling
+func (v *ClientView) hasPrefix(path string, prefix string) bool {
+	if v.CaseSensitive {
+		return strings.HasPrefix(path, prefix)
+	}
+	return len(path) >= len(prefix) && strings.EqualFold(path[:len(prefix)], prefix)
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// pathHasPrefix reports whether path is root or lies below it
+func pathHasPrefix(path string, root string, caseSensitive bool) bool {
+	path = filepath.Clean(path)
+	root = filepath.Clean(root)
+
+	if len(path) < len(root) {
+		return false
+	}
+	head := path[:len(root)]
+	if caseSensitive {
+		if head != root {
+			return false
+		}
+	} else if !strings.EqualFold(head, root) {
+		return false
+	}
+
+	rest := path[len(root):]
+	return rest == "" || rest[0] == '/' || rest[0] == '\\' || strings.HasSuffix(root, string(filepath.Separator))
+}
+
+// p4PathEscapes maps the characters Perforce escapes in depot and client syntax
+var p4PathEscapes = strings.NewReplacer("%40", "@", "%23", "#", "%2A", "*", "%2a", "*", "%25", "%")
+
+// unescapeP4Path turns %40, %23, %2A and %25 back into literal characters
+func unescapeP4Path(path string) string {
+	return p4PathEscapes.Replace(path)
+}
+
+// escapeP4Path escapes @, #, * and % for use in depot or client syntax
+func escapeP4Path(path string) string {
+	return strings.NewReplacer("%", "%25", "@", "%40", "#", "%23", "*", "%2A").Replace(path)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseClientViewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec P4Record
+	}{
+		{"no client name", P4Record{"Root": "/ws", "View0": "//depot/... //ws/..."}},
+		{"one-sided view line", P4Record{"Client": "ws", "Root": "/ws", "View0": "//depot/..."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseClientView(tt.spec, true); err == nil {
+				t.Error("parseClientView() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestClientViewTranslate(t *testing.T) {
+	view := newTestClientView(t, "/ws", true,
+		"//depot/Game/... //ws/Game/...",
+		"-//depot/Game/Secret/... //ws/Game/Secret/...",
+		`"//depot/Game/My Maps/..." "//ws/Game/Maps/..."`,
+		"+//depot/Shared/... //ws/Game/...",
+		"//depot/Art/%%1.%%2 //ws/Art/%%2/%%1",
+	)
+
+	tests := []struct {
+		name      string
+		depotPath string
+		wantLocal string
+		wantOK    bool
+	}{
+		{"plain mapping", "//depot/Game/Content/Hero.uasset", "/ws/Game/Content/Hero.uasset", true},
+		{"exclusion", "//depot/Game/Secret/Plan.txt", "", false},
+		{"quoted path with spaces", "//depot/Game/My Maps/Level.umap", "/ws/Game/Maps/Level.umap", true},
+		{"overlay", "//depot/Shared/Config/Default.ini", "/ws/Game/Config/Default.ini", true},
+		{"positional wildcards", "//depot/Art/hero.png", "/ws/Art/png/hero", true},
+		{"outside the view", "//depot/Other/readme.txt", "", false},
+		{"escaped characters", "//depot/Game/a%40b.txt", "/ws/Game/a@b.txt", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, ok := view.DepotToLocal(tt.depotPath)
+			want := ""
+			if tt.wantLocal != "" {
+				want = filepath.FromSlash(tt.wantLocal)
+			}
+			if local != want || ok != tt.wantOK {
+				t.Errorf("DepotToLocal(%q) = %q, %v, want %q, %v", tt.depotPath, local, ok, want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClientViewLocalToDepot(t *testing.T) {
+	view := newTestClientView(t, "/ws", false,
+		"//depot/Game/... //ws/Game/...",
+		"-//depot/Game/Secret/... //ws/Game/Secret/...",
+	)
+
+	tests := []struct {
+		name      string
+		localPath string
+		wantDepot string
+		wantOK    bool
+	}{
+		{"plain mapping", "/ws/Game/Content/Hero.uasset", "//depot/Game/Content/Hero.uasset", true},
+		{"case-insensitive server", "/WS/game/Content/Hero.uasset", "//depot/Game/Content/Hero.uasset", true},
+		{"excluded", "/ws/Game/Secret/Plan.txt", "", false},
+		{"outside the root", "/elsewhere/Game/a.txt", "", false},
+		{"needs escaping", "/ws/Game/a#1.txt", "//depot/Game/a%231.txt", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depot, ok := view.LocalToDepot(filepath.FromSlash(tt.localPath))
+			if depot != tt.wantDepot || ok != tt.wantOK {
+				t.Errorf("LocalToDepot(%q) = %q, %v, want %q, %v", tt.localPath, depot, ok, tt.wantDepot, tt.wantOK)
+			}
+		})
+	}
+}
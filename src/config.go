@@ -4,11 +4,21 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	RecentFolders []RecentFolder `json:"recent_folders"`
+	RecentFolders    []RecentFolder `json:"recent_folders"`
+	P4TimeoutSeconds int            `json:"p4_timeout_seconds,omitempty"`
+	ScanConcurrency  int            `json:"scan_concurrency,omitempty"`
+	DetectMode       string         `json:"detect_mode,omitempty"`
+	WatchWorkspace   bool           `json:"watch_workspace,omitempty"`
+	IgnorePreset     string         `json:"ignore_preset,omitempty"`
+	PollSubmits      bool           `json:"poll_submits,omitempty"`
+	PollSeconds      int            `json:"poll_seconds,omitempty"`
+	EditorPath       string         `json:"editor_path,omitempty"`
+	ProjectFile      string         `json:"project_file,omitempty"`
 }
 
 type RecentFolder struct {
@@ -17,11 +27,77 @@ type RecentFolder struct {
 	UseCount  int       `json:"use_count"`
 }
 
+// defaultP4Timeout bounds a single p4 command when the config sets no timeout
+const defaultP4Timeout = 30 * time.Minute
+
+// P4Timeout returns the per-command p4 timeout; a negative setting disables it
+func (c *Config) P4Timeout() time.Duration {
+	if c.P4TimeoutSeconds < 0 {
+		return 0
+	}
+	if c.P4TimeoutSeconds == 0 {
+		return defaultP4Timeout
+	}
+	return time.Duration(c.P4TimeoutSeconds) * time.Second
+}
+
+// ScanWorkers returns how many folders are reconciled in parallel
+func (c *Config) ScanWorkers() int {
+	if c.ScanConcurrency < 1 {
+		return defaultScanConcurrency
+	}
+	return c.ScanConcurrency
+}
+
+// ScanDetectMode returns how unopened modified files are found by default
+func (c *Config) ScanDetectMode() string {
+	if c.DetectMode == detectModeDigest {
+		return detectModeDigest
+	}
+	return detectModeReconcile
+}
+
+// defaultPollInterval is how often the submit poller checks for new submits
+const defaultPollInterval = 2 * time.Minute
+
+// PollInterval returns how often the submit poller runs (at least 10 seconds)
+func (c *Config) PollInterval() time.Duration {
+	if c.PollSeconds <= 0 {
+		return defaultPollInterval
+	}
+	if c.PollSeconds < 10 {
+		return 10 * time.Second
+	}
+	return time.Duration(c.PollSeconds) * time.Second
+}
+
+// ScanIgnorePreset returns the built-in ignore rules to apply ("none" unless
+// the UE preset was turned on in settings)
+func (c *Config) ScanIgnorePreset() string {
+	if c.IgnorePreset == ignorePresetUE {
+		return ignorePresetUE
+	}
+	return ignorePresetNone
+}
+
 func getConfigPath() string {
 	homeDir, _ := os.UserHomeDir()
 	return filepath.Join(homeDir, ".p4chimari.json")
 }
 
+// workspaceStatePath returns a per-workspace state file stored next to the
+// config file, keyed by client name and server so two servers with the same
+// client name never share state, e.g. ".p4chimari_scancache_<client>@<server>.json"
+func workspaceStatePath(prefix string, clientName string, serverAddr string) string {
+	safeName := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\/:*?"<>| `, r) {
+			return '_'
+		}
+		return r
+	}, clientName+"@"+serverAddr)
+	return filepath.Join(filepath.Dir(getConfigPath()), prefix+safeName+".json")
+}
+
 func loadConfig() (*Config, error) {
 	configPath := getConfigPath()
 
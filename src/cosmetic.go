@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// isTextType reports whether a Perforce file type is diffed line by line
+func isTextType(fileType string) bool {
+	base, _, _ := strings.Cut(fileType, "+")
+	switch base {
+	case "text", "ktext", "xtext", "kxtext", "unicode", "xunicode", "utf8", "xutf8", "utf16", "xutf16":
+		return true
+	}
+	return false
+}
+
+// findCosmeticChanges returns which of the given opened depot files are text
+// files that only differ from their have revision in line endings or
+// trailing whitespace. Text files are first diffed with batched
+// "p4 diff -dlb" runs (ignore line endings and whitespace amount); since -db
+// also hides indentation and other inner whitespace edits, each file without
+// diff lines is then compared locally with its have revision. Stops early
+// (with what it found) when ctx is cancelled.
+func findCosmeticChanges(ctx context.Context, depotFiles []string) map[string]bool {
+	cosmetic := make(map[string]bool)
+
+	// Binary files have no diff lines either, so only text types are diffed
+	textFiles := make(map[string]FileRecord)
+	var textPaths []string
+	for _, chunk := range chunkFiles(depotFiles, batchMaxFiles, batchMaxBytes) {
+		if ctx.Err() != nil {
+			return cosmetic
+		}
+
+		records, _ := runP4TaggedArgFile(ctx, chunk, "fstat", "-T", "depotFile,clientFile,type,headType,haveRev")
+		for _, r := range records {
+			if record := fileRecordFrom(r); record.DepotFile != "" && record.HaveRev > 0 && isTextType(record.Type) {
+				textFiles[record.DepotFile] = record
+				textPaths = append(textPaths, record.DepotFile)
+			}
+		}
+	}
+
+	var candidates []FileRecord
+	for _, chunk := range chunkFiles(textPaths, batchMaxFiles, batchMaxBytes) {
+		if ctx.Err() != nil {
+			return cosmetic
+		}
+
+		// diff exits non-zero when files differ; the output is still complete
+		output, err := runP4Input(ctx, strings.Join(chunk, "\n")+"\n", "-x", "-", "diff", "-dlb")
+		if isInterrupted(ctx, err) {
+			return cosmetic
+		}
+		for depotFile := range filesWithoutDiffLines(output) {
+			if record, ok := textFiles[depotFile]; ok {
+				candidates = append(candidates, record)
+			}
+		}
+	}
+	resolveLocalPaths(ctx, candidates)
+
+	for _, record := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+
+		local, err := os.ReadFile(recordLocalPath(record))
+		if err != nil {
+			continue
+		}
+		depot, err := runP4(ctx, "print", "-q", fmt.Sprintf("%s#%d", record.DepotFile, record.HaveRev))
+		if err != nil {
+			continue
+		}
+		if onlyLineEndingsDiffer(local, depot) {
+			cosmetic[record.DepotFile] = true
+		}
+	}
+
+	return cosmetic
+}
+
+// onlyLineEndingsDiffer reports whether two texts have the same lines once
+// CR line endings and trailing spaces and tabs are dropped
+func onlyLineEndingsDiffer(a []byte, b []byte) bool {
+	linesA := bytes.Split(a, []byte("\n"))
+	linesB := bytes.Split(b, []byte("\n"))
+	if len(linesA) != len(linesB) {
+		return false
+	}
+
+	for i := range linesA {
+		if !bytes.Equal(bytes.TrimRight(linesA[i], " \t\r"), bytes.TrimRight(linesB[i], " \t\r")) {
+			return false
+		}
+	}
+	return true
+}
+
+// filesWithoutDiffLines parses "p4 diff" output and returns the depot files
+// whose "==== //depot/file#rev - local ====" header has no diff lines under it
+func filesWithoutDiffLines(output []byte) map[string]bool {
+	empty := make(map[string]bool)
+
+	current := ""
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "==== //") {
+			depotFile, _, _ := strings.Cut(strings.TrimPrefix(line, "==== "), "#")
+			current = depotFile
+			empty[current] = true
+			continue
+		}
+		if current != "" && line != "" {
+			delete(empty, current)
+		}
+	}
+
+	return empty
+}
+
+// splitCosmeticChanges splits changed depot files into real changes and
+// changes to line endings or whitespace only
+func splitCosmeticChanges(ctx context.Context, files []string) ([]string, []string) {
+	cosmetic := findCosmeticChanges(ctx, files)
+	if len(cosmetic) == 0 {
+		return files, nil
+	}
+
+	var real []string
+	var cosmeticOnly []string
+	for _, file := range files {
+		if cosmetic[file] {
+			cosmeticOnly = append(cosmeticOnly, file)
+		} else {
+			real = append(real, file)
+		}
+	}
+	return real, cosmeticOnly
+}
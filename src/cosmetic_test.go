@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilesWithoutDiffLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   map[string]bool
+	}{
+		{"no output", "", map[string]bool{}},
+		{
+			name:   "header only",
+			output: "==== //depot/a.txt#3 - /ws/a.txt ====\n",
+			want:   map[string]bool{"//depot/a.txt": true},
+		},
+		{
+			name:   "header with diff lines",
+			output: "==== //depot/a.txt#3 - /ws/a.txt ====\n1c1\n< old\n---\n> new\n",
+			want:   map[string]bool{},
+		},
+		{
+			name: "mixed",
+			output: "==== //depot/a.txt#3 - /ws/a.txt ====\n" +
+				"==== //depot/b.txt#1 - /ws/b.txt ====\r\n2a3\r\n> added\r\n" +
+				"==== //depot/c.ini#7 - /ws/c.ini ====\n\n",
+			want: map[string]bool{"//depot/a.txt": true, "//depot/c.ini": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filesWithoutDiffLines([]byte(tt.output)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filesWithoutDiffLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnlyLineEndingsDiffer(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want bool
+	}{
+		{"identical", "a\nb\n", "a\nb\n", true},
+		{"CRLF vs LF", "a\r\nb\r\n", "a\nb\n", true},
+		{"trailing whitespace", "a  \nb\t\n", "a\nb\n", true},
+		{"indentation changed", "  a\nb\n", "a\nb\n", false},
+		{"inner whitespace changed", "a b\n", "a  b\n", false},
+		{"line added", "a\nb\nc\n", "a\nb\n", false},
+		{"text changed", "a\nc\n", "a\nb\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := onlyLineEndingsDiffer([]byte(tt.a), []byte(tt.b)); got != tt.want {
+				t.Errorf("onlyLineEndingsDiffer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTextType(t *testing.T) {
+	tests := []struct {
+		fileType string
+		want     bool
+	}{
+		{"text", true},
+		{"text+k", true},
+		{"utf16", true},
+		{"binary+l", false},
+		{"symlink", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileType, func(t *testing.T) {
+			if got := isTextType(tt.fileType); got != tt.want {
+				t.Errorf("isTextType(%q) = %v, want %v", tt.fileType, got, tt.want)
+			}
+		})
+	}
+}
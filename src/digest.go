@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Detection modes for "modified but not opened" files
+const (
+	detectModeReconcile = "reconcile" // p4 reconcile -n (server compares)
+	detectModeDigest    = "digest"    // fstat digests compared with local MD5s
+)
+
+// detectMode selects how unopened modified files are found
+var detectMode = detectModeReconcile
+
+// digestCheck is the outcome of comparing one have revision with its local file
+type digestCheck int
+
+const (
+	digestSame digestCheck = iota
+	digestModified
+	digestMissing
+	digestUnknown // file type or missing digest makes a local comparison impossible
+)
+
+// ============================================================================
+// DIGEST SCAN
+// ============================================================================
+
+// getModifiedFilesByDigest finds unopened files that differ from (or are
+// missing compared to) the have revision by hashing them locally. Only one
+// fstat per folder is sent to the server.
+func getModifiedFilesByDigest(ctx context.Context, folders []string, verbose bool) ([]ModifiedFile, error) {
+	var records []FileRecord
+	for _, folder := range folders {
+		if verbose {
+			fmt.Printf("  Executing: p4 -ztag fstat -Ol -Rh %s#have\n", folderSpec(folder))
+		}
+		folderRecords, err := p4FstatDigests(ctx, folderSpec(folder))
+		if isInterrupted(ctx, err) {
+			return nil, err
+		}
+		// fstat exits non-zero for a folder with nothing synced; any other
+		// failure must not pass for a clean folder
+		if err != nil && !isNoMatch(err) {
+			return nil, fmt.Errorf("fstat of %s failed: %w", folderSpec(folder), err)
+		}
+		for _, record := range folderRecords {
+			// Opened files are covered by the diff -se / -sr steps
+			if record.Action == "" && record.LocalPath != "" {
+				records = append(records, record)
+			}
+		}
+	}
+
+	if verbose {
+		fmt.Printf("  Checking %d file(s) locally with %d worker(s)...\n", len(records), runtime.NumCPU())
+	}
+
+	// Files whose size and mtime are unchanged since the last scan reuse
+	// their cached digest instead of being hashed again
+	var cache *ScanCache
+	if view, viewErr := getClientView(ctx); viewErr == nil {
+		cache = loadScanCache(view.ClientName, view.ServerAddr)
+	}
+
+	checks, cached, err := checkDigestsParallel(ctx, records, runtime.NumCPU(), cache)
+	if saveErr := cache.Save(); saveErr != nil && verbose {
+		fmt.Printf("  ⚠ %v\n", saveErr)
+	}
+
+	files := []ModifiedFile{}
+	unknown := 0
+	for i, check := range checks {
+		switch check {
+		case digestModified:
+			files = append(files, ModifiedFile{Path: filepath.Clean(records[i].LocalPath), Action: "edit", HasChanges: true, FileDetails: detailsFromRecord(records[i])})
+		case digestMissing:
+			files = append(files, ModifiedFile{Path: filepath.Clean(records[i].LocalPath), Action: "delete", HasChanges: true, FileDetails: detailsFromRecord(records[i])})
+		case digestUnknown:
+			unknown++
+		}
+	}
+
+	if verbose && cache != nil {
+		fmt.Printf("  ✓ %d of %d file(s) unchanged since the last scan (from scan cache)\n", cached, len(records))
+	}
+	if verbose && unknown > 0 {
+		fmt.Printf("  ⚠ %d file(s) could not be compared locally (keyword/unicode types or no server digest) - use reconcile mode for those\n", unknown)
+	}
+
+	return files, err
+}
+
+// checkDigestsParallel compares every record with its local file on a worker
+// pool and returns how many results came from the cache. On cancellation the
+// unchecked records are left as digestSame and errCancelled is returned.
+func checkDigestsParallel(ctx context.Context, records []FileRecord, workers int, cache *ScanCache) ([]digestCheck, int, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	checks := make([]digestCheck, len(records))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var cachedMu sync.Mutex
+	cached := 0
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				check, fromCache := checkLocalDigest(records[index], cache)
+				checks[index] = check
+				if fromCache {
+					cachedMu.Lock()
+					cached++
+					cachedMu.Unlock()
+				}
+			}
+		}()
+	}
+
+	var err error
+	for index := range records {
+		if ctx.Err() != nil {
+			err = errCancelled
+			break
+		}
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	return checks, cached, err
+}
+
+// checkLocalDigest compares a have revision with the file on disk, using
+// the scan cache when the file's size and mtime are unchanged
+func checkLocalDigest(record FileRecord, cache *ScanCache) (digestCheck, bool) {
+	info, err := os.Stat(record.LocalPath)
+	if os.IsNotExist(err) {
+		cache.forget(record.LocalPath)
+		return digestMissing, false
+	}
+	if err != nil || info.IsDir() {
+		return digestUnknown, false
+	}
+
+	isText, ok := digestFileKind(record.Type)
+	if !ok || record.Digest == "" {
+		return digestUnknown, false
+	}
+
+	if entry, hit := cache.lookup(record.LocalPath, info); hit {
+		if entry.Digest != "" {
+			return compareDigests(entry.Digest, record.Digest), true
+		}
+		if strings.EqualFold(entry.HaveDigest, record.Digest) {
+			return digestStatusCheck(entry.Status), true
+		}
+	}
+
+	// Binary files are stored byte for byte, so a size change is conclusive
+	if !isText && record.FileSize > 0 && info.Size() != record.FileSize {
+		cache.store(record.LocalPath, info, "", record.Digest, "edit")
+		return digestModified, false
+	}
+
+	localDigest, err := localFileDigest(record.LocalPath, isText)
+	if err != nil {
+		return digestUnknown, false
+	}
+
+	check := compareDigests(localDigest, record.Digest)
+	status := "same"
+	if check == digestModified {
+		status = "edit"
+	}
+	cache.store(record.LocalPath, info, localDigest, record.Digest, status)
+
+	return check, false
+}
+
+// compareDigests compares a local digest with the server digest
+func compareDigests(localDigest string, serverDigest string) digestCheck {
+	if strings.EqualFold(localDigest, serverDigest) {
+		return digestSame
+	}
+	return digestModified
+}
+
+// digestStatusCheck converts a cached status back into a digestCheck
+func digestStatusCheck(status string) digestCheck {
+	if status == "edit" {
+		return digestModified
+	}
+	return digestSame
+}
+
+// digestFileKind reports whether a file type is hashed as text, and whether
+// its server digest can be reproduced locally at all
+func digestFileKind(fileType string) (isText bool, ok bool) {
+	base, modifiers, _ := strings.Cut(fileType, "+")
+
+	// Keyword expansion changes the workspace copy
+	if strings.Contains(modifiers, "k") {
+		return false, false
+	}
+
+	switch base {
+	case "text", "xtext", "utf8":
+		return true, true
+	case "binary", "xbinary", "ubinary", "uxbinary", "":
+		return false, true
+	default:
+		// ktext, unicode, utf16, symlink, apple, resource...
+		return false, false
+	}
+}
+
+// localFileDigest returns the uppercase hex MD5 of a local file. Text files are
+// hashed with CRLF normalised to LF, matching the server's stored form.
+func localFileDigest(path string, isText bool) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := md5.New()
+	var writer io.Writer = hasher
+	normalizer := &crlfNormalizer{hash: hasher}
+	if isText {
+		writer = normalizer
+	}
+
+	if _, err := io.Copy(writer, file); err != nil {
+		return "", err
+	}
+	if isText {
+		normalizer.flush()
+	}
+
+	return strings.ToUpper(hex.EncodeToString(hasher.Sum(nil))), nil
+}
+
+// crlfNormalizer writes to a hash with every CRLF replaced by LF. A CR at the
+// end of one chunk is held back until the next chunk shows what follows it.
+type crlfNormalizer struct {
+	hash      hash.Hash
+	pendingCR bool
+}
+
+// Write hashes p with CRLF line endings converted to LF
+func (n *crlfNormalizer) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p)+1)
+
+	for i, b := range p {
+		if n.pendingCR {
+			n.pendingCR = false
+			if b != '\n' {
+				out = append(out, '\r')
+			}
+		}
+		if b == '\r' {
+			if i == len(p)-1 {
+				n.pendingCR = true
+				continue
+			}
+			if p[i+1] == '\n' {
+				continue
+			}
+		}
+		out = append(out, b)
+	}
+
+	n.hash.Write(out)
+	return len(p), nil
+}
+
+// flush hashes a CR left over at the very end of the file
+func (n *crlfNormalizer) flush() {
+	if n.pendingCR {
+		n.hash.Write([]byte{'\r'})
+		n.pendingCR = false
+	}
+}
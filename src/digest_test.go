@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCRLFNormalizer(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{"no CR", []string{"a\nb\n"}, "a\nb\n"},
+		{"CRLF", []string{"a\r\nb\r\n"}, "a\nb\n"},
+		{"lone CR is kept", []string{"a\rb"}, "a\rb"},
+		{"CRLF split across writes", []string{"a\r", "\nb"}, "a\nb"},
+		{"CR at the end of a write then text", []string{"a\r", "b"}, "a\rb"},
+		{"CR at the end of the file", []string{"a\r"}, "a\r"},
+		{"double CR before LF", []string{"a\r\r\n"}, "a\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := md5.New()
+			normalizer := &crlfNormalizer{hash: hasher}
+			for _, chunk := range tt.chunks {
+				normalizer.Write([]byte(chunk))
+			}
+			normalizer.flush()
+
+			want := md5.Sum([]byte(tt.want))
+			if got := hasher.Sum(nil); string(got) != string(want[:]) {
+				t.Errorf("hash of %q does not match hash of %q", strings.Join(tt.chunks, ""), tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalFileDigest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("line\r\nline\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		isText bool
+		hashed string
+	}{
+		{"text is hashed with LF line endings", true, "line\nline\n"},
+		{"binary is hashed as is", false, "line\r\nline\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum := md5.Sum([]byte(tt.hashed))
+			want := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+			got, err := localFileDigest(path, tt.isText)
+			if err != nil || got != want {
+				t.Errorf("localFileDigest() = %q, %v, want %q", got, err, want)
+			}
+		})
+	}
+}
+
+func TestDigestFileKind(t *testing.T) {
+	tests := []struct {
+		fileType   string
+		wantIsText bool
+		wantOK     bool
+	}{
+		{"text", true, true},
+		{"text+w", true, true},
+		{"binary+l", false, true},
+		{"", false, true},
+		{"text+k", false, false},
+		{"ktext", false, false},
+		{"utf16", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileType, func(t *testing.T) {
+			isText, ok := digestFileKind(tt.fileType)
+			if isText != tt.wantIsText || ok != tt.wantOK {
+				t.Errorf("digestFileKind(%q) = %v, %v, want %v, %v", tt.fileType, isText, ok, tt.wantIsText, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGetModifiedFilesByDigestErrors(t *testing.T) {
+	exit1 := errors.New("exit status 1")
+
+	tests := []struct {
+		name     string
+		response FakeResponse
+		wantErr  bool
+	}{
+		{"nothing synced", FakeResponse{Stderr: "//ws/Content/...#have - file(s) not on client.\n", Err: exit1}, false},
+		{"no such files", FakeResponse{Stderr: "//ws/Content/...#have - no such file(s).\n", Err: exit1}, false},
+		{"server unreachable", FakeResponse{Stderr: "Connect to server failed; check $P4PORT.\n", Err: exit1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempHome(t)
+			root, fake := setupFakeWorkspace(t)
+			folder := filepath.Join(root, "Content")
+			fake.On("-ztag fstat -Ol -Rh -T depotFile,clientFile,headType,haveRev,action,fileSize,digest "+folderSpec(folder)+"#have", tt.response)
+
+			files, err := getModifiedFilesByDigest(context.Background(), []string{folder}, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getModifiedFilesByDigest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(files) != 0 {
+				t.Errorf("getModifiedFilesByDigest() = %v, want no files", files)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileDetails is what p4 and the local filesystem know about a scanned file
+type FileDetails struct {
+	DepotPath string
+	LocalPath string // empty when the file is not mapped locally
+	HaveRev   int
+	HeadRev   int
+	FileType  string // e.g. "text", "binary+l"
+	Change    string // pending changelist ("default" or a number) of opened files
+	Size      int64  // local size in bytes, -1 when the file is missing locally
+	ModTime   time.Time
+	MovedFile string // depot path of the other half of a rename reported by p4
+}
+
+// detailsFromRecord copies what a tagged record already tells about a file
+func detailsFromRecord(record FileRecord) FileDetails {
+	details := FileDetails{
+		DepotPath: record.DepotFile,
+		HaveRev:   record.HaveRev,
+		HeadRev:   record.HeadRev,
+		FileType:  record.Type,
+		Change:    record.Change,
+		MovedFile: record.MovedFile,
+	}
+
+	localPath := recordLocalPath(record)
+	if localPath != record.DepotFile {
+		details.LocalPath = localPath
+	}
+
+	return details
+}
+
+// fillFileDetails completes revisions, file type and changelist with batched
+// "p4 fstat" calls and reads local size and mtime from disk
+func fillFileDetails(ctx context.Context, details []*FileDetails) {
+	byDepot := make(map[string][]*FileDetails)
+	var depotPaths []string
+	for _, d := range details {
+		if d.DepotPath == "" {
+			continue
+		}
+		if _, ok := byDepot[d.DepotPath]; !ok {
+			depotPaths = append(depotPaths, d.DepotPath)
+		}
+		byDepot[d.DepotPath] = append(byDepot[d.DepotPath], d)
+	}
+
+	for _, chunk := range chunkFiles(depotPaths, batchMaxFiles, batchMaxBytes) {
+		if ctx.Err() != nil {
+			break
+		}
+
+		// Files only opened for add are unknown to the depot and make
+		// fstat exit non-zero; the records for the others are still valid
+		records, _ := runP4TaggedArgFile(ctx, chunk, "fstat")
+		for _, r := range records {
+			record := fileRecordFrom(r)
+			for _, d := range byDepot[record.DepotFile] {
+				d.HaveRev = record.HaveRev
+				d.HeadRev = record.HeadRev
+				if record.Type != "" {
+					d.FileType = record.Type
+				}
+				if record.Change != "" {
+					d.Change = record.Change
+				}
+				if d.LocalPath == "" && record.LocalPath != "" {
+					d.LocalPath = filepath.Clean(record.LocalPath)
+				}
+			}
+		}
+	}
+
+	for _, d := range details {
+		d.Size = -1
+		if d.LocalPath == "" {
+			continue
+		}
+		if info, err := os.Stat(d.LocalPath); err == nil {
+			d.Size = info.Size()
+			d.ModTime = info.ModTime()
+		}
+	}
+}
+
+// enrichModifiedFiles fills in the details of scanned files in one pass
+func enrichModifiedFiles(ctx context.Context, fileLists ...[]ModifiedFile) {
+	var details []*FileDetails
+	for _, files := range fileLists {
+		for i := range files {
+			details = append(details, &files[i].FileDetails)
+		}
+	}
+	fillFileDetails(ctx, details)
+}
+
+// RevLabel formats the revisions as "#have/#head" ("new" for files not in the depot)
+func (d FileDetails) RevLabel() string {
+	if d.HeadRev == 0 {
+		return "new"
+	}
+	return fmt.Sprintf("#%d/#%d", d.HaveRev, d.HeadRev)
+}
+
+// ChangeLabel returns the changelist, or "-" for files that are not opened
+func (d FileDetails) ChangeLabel() string {
+	if d.Change == "" {
+		return "-"
+	}
+	return d.Change
+}
+
+// TypeLabel returns the file type, or "-" when unknown
+func (d FileDetails) TypeLabel() string {
+	if d.FileType == "" {
+		return "-"
+	}
+	return d.FileType
+}
+
+// SizeLabel returns the local size in human-readable form
+func (d FileDetails) SizeLabel() string {
+	if d.Size < 0 {
+		return "missing"
+	}
+	return formatSize(d.Size)
+}
+
+// ModTimeLabel returns the local modification time, or "-" when missing
+func (d FileDetails) ModTimeLabel() string {
+	if d.ModTime.IsZero() {
+		return "-"
+	}
+	return d.ModTime.Format("2006-01-02 15:04")
+}
+
+// formatSize formats a byte count as B, KB, MB or GB
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// printFileTable prints files in aligned columns, up to limit rows
+func printFileTable(files []ModifiedFile, limit int) {
+	fmt.Printf("  %-8s %-14s %-11s %-8s %10s  %-16s  %s\n", "ACTION", "TYPE", "REV", "CL", "SIZE", "MODIFIED", "PATH")
+	fmt.Printf("  %-8s %-14s %-11s %-8s %10s  %-16s  %s\n", "──────", "────", "───", "──", "────", "────────", "────")
+
+	for i, file := range files {
+		if i >= limit {
+			fmt.Printf("  ... and %d more\n", len(files)-limit)
+			break
+		}
+		fmt.Printf("  %-8s %-14s %-11s %-8s %10s  %-16s  %s\n",
+			strings.ToUpper(file.Action),
+			truncate(file.TypeLabel(), 14),
+			file.RevLabel(),
+			truncate(file.ChangeLabel(), 8),
+			file.SizeLabel(),
+			file.ModTimeLabel(),
+			movePathLabel(file.Path, file.MovedFrom))
+	}
+}
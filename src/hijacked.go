@@ -2,158 +2,271 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
+// baselineHistoryLimit is how many snapshots are kept per workspace
+const baselineHistoryLimit = 20
+
 // Baseline stores the snapshot of files checked out when UE was opened
 type Baseline struct {
-	Timestamp time.Time `json:"timestamp"`
-	Files     []string  `json:"files"`
+	Name      string            `json:"name"`
+	Timestamp time.Time         `json:"timestamp"`
+	Files     []string          `json:"files"`
+	Changes   map[string]string `json:"changes,omitempty"` // depot file -> pending changelist
+}
+
+// BaselineHistory is every snapshot of one workspace, oldest first
+type BaselineHistory struct {
+	Client    string     `json:"client"`
+	Server    string     `json:"server"`
+	Snapshots []Baseline `json:"snapshots"`
+}
+
+// BaselineDiff is what changed between two snapshots
+type BaselineDiff struct {
+	Added   []string
+	Removed []string
+	Moved   []BaselineMove
+}
+
+// BaselineMove is a file that changed pending changelist between snapshots
+type BaselineMove struct {
+	File string
+	From string
+	To   string
 }
 
-// getBaselinePath returns the path to the baseline file
-func getBaselinePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+// getBaselinePath returns the baseline history file of a workspace, stored
+// next to the config file and keyed by client name and server
+func getBaselinePath(p4Info *P4Info) string {
+	return workspaceStatePath(".p4chimari_baselines_", p4Info.ClientName, p4Info.ServerAddr)
+}
+
+// loadBaselineHistory loads the snapshots of a workspace (empty if there are none)
+func loadBaselineHistory(p4Info *P4Info) (*BaselineHistory, error) {
+	history := &BaselineHistory{Client: p4Info.ClientName, Server: p4Info.ServerAddr}
+
+	data, err := os.ReadFile(getBaselinePath(p4Info))
 	if err != nil {
-		return "", err
+		if os.IsNotExist(err) {
+			if err := migrateLegacyBaseline(p4Info, history); err != nil {
+				return nil, err
+			}
+			return history, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, history); err != nil {
+		return nil, fmt.Errorf("failed to parse baselines: %v", err)
 	}
-	return filepath.Join(homeDir, ".p4chimari_baseline.json"), nil
+
+	return history, nil
 }
 
-// captureBaseline saves current opened files as baseline (hijacked files)
-func captureBaseline() error {
-	fmt.Println("\n📸 Capturing baseline (hijacked files)...")
-	fmt.Println("─────────────────────────────────────")
+// legacyBaselinePath is the single baseline file older versions shared
+// between every workspace on the machine
+func legacyBaselinePath() string {
+	return filepath.Join(filepath.Dir(getConfigPath()), ".p4chimari_baseline.json")
+}
 
-	// Get currently opened files
-	openedFiles, err := getPendingFiles()
+// migrateLegacyBaseline imports the old shared baseline as the oldest
+// snapshot of the first workspace that loads its history, then renames the
+// old file to .migrated so no other workspace imports it again
+func migrateLegacyBaseline(p4Info *P4Info, history *BaselineHistory) error {
+	legacyPath := legacyBaselinePath()
+	data, err := os.ReadFile(legacyPath)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var legacy Baseline
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", legacyPath, err)
+	}
+	if legacy.Name == "" {
+		legacy.Name = "imported " + legacy.Timestamp.Format("2006-01-02 15:04:05")
+	}
+	history.Snapshots = append([]Baseline{legacy}, history.Snapshots...)
+
+	if err := history.save(p4Info); err != nil {
+		return err
+	}
+	if err := os.Rename(legacyPath, legacyPath+".migrated"); err != nil {
+		return fmt.Errorf("failed to rename %s: %v", legacyPath, err)
+	}
+
+	fmt.Printf("✓ Imported the old shared baseline into %s as '%s'\n", p4Info.ClientName, legacy.Name)
+	return nil
+}
+
+// save writes the snapshots to disk, dropping the oldest beyond baselineHistoryLimit
+func (h *BaselineHistory) save(p4Info *P4Info) error {
+	if len(h.Snapshots) > baselineHistoryLimit {
+		h.Snapshots = h.Snapshots[len(h.Snapshots)-baselineHistoryLimit:]
+	}
+
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baselines: %v", err)
+	}
+
+	if err := os.WriteFile(getBaselinePath(p4Info), data, 0644); err != nil {
+		return fmt.Errorf("failed to write baselines: %v", err)
+	}
+	return nil
+}
+
+// captureBaseline saves the currently opened files as a named snapshot, so
+// files the editor opens afterwards can be told apart from earlier checkouts.
+// A snapshot with the same name is replaced; an empty name uses the time.
+func captureBaseline(ctx context.Context, p4Info *P4Info, name string) error {
+	fmt.Println("\n📸 Capturing baseline (files already checked out)...")
+	fmt.Println("─────────────────────────────────────")
+
+	// Get currently opened files; p4 exits non-zero when there are none. Any
+	// other failure must not save an empty baseline, or the session review
+	// would treat every earlier checkout as opened by the editor.
+	records, err := p4Opened(ctx)
+	if err != nil && !isNoMatch(err) {
 		return fmt.Errorf("failed to get opened files: %v", err)
 	}
 
 	baseline := Baseline{
+		Name:      name,
 		Timestamp: time.Now(),
-		Files:     openedFiles,
+		Files:     []string{},
+		Changes:   make(map[string]string),
+	}
+	if baseline.Name == "" {
+		baseline.Name = baseline.Timestamp.Format("2006-01-02 15:04:05")
+	}
+	for _, record := range records {
+		change := record.Change
+		if change == "" {
+			change = "default"
+		}
+		baseline.Files = append(baseline.Files, record.DepotFile)
+		baseline.Changes[record.DepotFile] = change
 	}
 
-	// Save to file
-	baselinePath, err := getBaselinePath()
+	history, err := loadBaselineHistory(p4Info)
 	if err != nil {
-		return fmt.Errorf("failed to get baseline path: %v", err)
+		return err
 	}
 
-	data, err := json.MarshalIndent(baseline, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal baseline: %v", err)
+	kept := history.Snapshots[:0]
+	for _, snapshot := range history.Snapshots {
+		if snapshot.Name != baseline.Name {
+			kept = append(kept, snapshot)
+		}
 	}
+	history.Snapshots = append(kept, baseline)
 
-	err = os.WriteFile(baselinePath, data, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write baseline: %v", err)
+	if err := history.save(p4Info); err != nil {
+		return err
 	}
 
-	fmt.Printf("✓ Captured %d hijacked file(s)\n", len(openedFiles))
-	fmt.Printf("  Timestamp: %s\n", baseline.Timestamp.Format("2006-01-02 15:04:05"))
-	fmt.Printf("  Saved to: %s\n", baselinePath)
+	fmt.Printf("✓ Captured %d checked-out file(s) as '%s'\n", len(baseline.Files), baseline.Name)
+	fmt.Printf("  Workspace: %s on %s\n", p4Info.ClientName, p4Info.ServerAddr)
+	fmt.Printf("  Saved to: %s\n", getBaselinePath(p4Info))
 
-	if len(openedFiles) > 0 {
-		fmt.Println("\nHijacked files:")
-		for i, file := range openedFiles {
+	if len(baseline.Files) > 0 {
+		fmt.Println("\nAlready checked out (left alone by the session cleanup):")
+		for i, file := range baseline.Files {
 			if i < 10 {
 				fmt.Printf("  • %s\n", file)
 			}
 		}
-		if len(openedFiles) > 10 {
-			fmt.Printf("  ... and %d more\n", len(openedFiles)-10)
+		if len(baseline.Files) > 10 {
+			fmt.Printf("  ... and %d more\n", len(baseline.Files)-10)
 		}
 	}
 
 	return nil
 }
 
-// loadBaseline loads the saved baseline
-func loadBaseline() (*Baseline, error) {
-	baselinePath, err := getBaselinePath()
-	if err != nil {
-		return nil, err
-	}
-
-	data, err := os.ReadFile(baselinePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("no baseline found - run 'Capture Baseline' first")
-		}
-		return nil, err
-	}
-
-	var baseline Baseline
-	err = json.Unmarshal(data, &baseline)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse baseline: %v", err)
+// clearBaseline removes every snapshot of the workspace
+func clearBaseline(p4Info *P4Info) error {
+	err := os.Remove(getBaselinePath(p4Info))
+	if err != nil && !os.IsNotExist(err) {
+		return err
 	}
 
-	return &baseline, nil
+	fmt.Printf("✓ Baselines cleared for %s\n", p4Info.ClientName)
+	return nil
 }
 
-// clearBaseline removes the saved baseline
-func clearBaseline() error {
-	baselinePath, err := getBaselinePath()
-	if err != nil {
-		return err
+// diffBaselines compares two snapshots: files only in to are added, files
+// only in from are removed, and files in both whose changelist differs moved
+func diffBaselines(from *Baseline, to *Baseline) BaselineDiff {
+	var diff BaselineDiff
+
+	inFrom := make(map[string]bool)
+	for _, file := range from.Files {
+		inFrom[file] = true
+	}
+	inTo := make(map[string]bool)
+	for _, file := range to.Files {
+		inTo[file] = true
 	}
 
-	err = os.Remove(baselinePath)
-	if err != nil && !os.IsNotExist(err) {
-		return err
+	for _, file := range to.Files {
+		if !inFrom[file] {
+			diff.Added = append(diff.Added, file)
+		} else if from.Changes[file] != to.Changes[file] && from.Changes != nil && to.Changes != nil {
+			diff.Moved = append(diff.Moved, BaselineMove{File: file, From: from.Changes[file], To: to.Changes[file]})
+		}
+	}
+	for _, file := range from.Files {
+		if !inTo[file] {
+			diff.Removed = append(diff.Removed, file)
+		}
 	}
 
-	fmt.Println("✓ Baseline cleared")
-	return nil
+	return diff
 }
 
 // getUnchangedFiles returns files that are opened but have no actual changes
 // These are likely hijacked files
-func getUnchangedFiles() ([]string, error) {
-	cmd := exec.Command("p4", "diff", "-sr")
-	output, _ := cmd.Output()
-
-	// p4 diff -sr returns opened files with no changes
-	// It may return exit code 1 if there are differences, which is expected
-	outputStr := string(output)
-
-	if len(outputStr) == 0 {
-		return []string{}, nil
+func getUnchangedFiles(ctx context.Context) ([]string, error) {
+	// p4 diff -sr returns opened files with no changes; it exits non-zero
+	// when nothing is opened, but any other failure must not make every
+	// opened file look like a real change
+	records, err := p4DiffStatus(ctx, "r", "")
+	if err != nil && !isNoMatch(err) {
+		return nil, err
 	}
 
-	var unchangedFiles []string
-	lines := strings.Split(outputStr, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		unchangedFiles = append(unchangedFiles, line)
+	// Depot paths, so the result can be compared with getPendingFiles
+	unchangedFiles := []string{}
+	for _, record := range records {
+		unchangedFiles = append(unchangedFiles, record.DepotFile)
 	}
 
 	return unchangedFiles, nil
 }
 
 // getRealChanges returns files that have actual changes (not hijacked)
-func getRealChanges() ([]string, []string, error) {
+func getRealChanges(ctx context.Context) ([]string, []string, error) {
 	// Get all opened files
-	openedFiles, err := getPendingFiles()
+	openedFiles, err := getPendingFiles(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get opened files: %v", err)
 	}
 
 	// Get unchanged files (hijacked)
-	unchangedFiles, err := getUnchangedFiles()
+	unchangedFiles, err := getUnchangedFiles(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get unchanged files: %v", err)
 	}
@@ -176,15 +289,225 @@ func getRealChanges() ([]string, []string, error) {
 		}
 	}
 
+	// Packages re-saved with only a new GUID or saved hash are hijacked too
+	headerOnly := findHeaderOnlyPackages(ctx, realChanges)
+	if len(headerOnly) > 0 {
+		var kept []string
+		for _, file := range realChanges {
+			if headerOnly[file] {
+				hijacked = append(hijacked, file)
+			} else {
+				kept = append(kept, file)
+			}
+		}
+		realChanges = kept
+	}
+
 	return realChanges, hijacked, nil
 }
 
-// revertHijackedFiles reverts files that were hijacked (unchanged)
-func revertHijackedFiles() error {
+// getSessionChanges returns the files opened since the baseline, split into
+// those still identical to the have revision and those really edited
+func getSessionChanges(ctx context.Context, baseline *Baseline) ([]string, []string, error) {
+	openedFiles, err := getPendingFiles(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get opened files: %v", err)
+	}
+
+	unchangedFiles, err := getUnchangedFiles(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get unchanged files: %v", err)
+	}
+
+	inBaseline := make(map[string]bool)
+	for _, file := range baseline.Files {
+		inBaseline[file] = true
+	}
+	unchangedMap := make(map[string]bool)
+	for _, file := range unchangedFiles {
+		unchangedMap[file] = true
+	}
+
+	var unchanged []string
+	var edited []string
+	for _, file := range openedFiles {
+		if inBaseline[file] {
+			continue
+		}
+		if unchangedMap[file] {
+			unchanged = append(unchanged, file)
+		} else {
+			edited = append(edited, file)
+		}
+	}
+
+	return unchanged, edited, nil
+}
+
+// getHijackedFiles returns the opened files that are identical to their have
+// revision, or packages differing only in volatile header fields, with their
+// changelist and local path
+func getHijackedFiles(ctx context.Context) ([]ModifiedFile, error) {
+	unchangedFiles, err := getUnchangedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unchangedMap := make(map[string]bool)
+	for _, file := range unchangedFiles {
+		unchangedMap[file] = true
+	}
+
+	// p4 exits non-zero when nothing is opened; any other failure is reported
+	// rather than shown as "no hijacked files"
+	records, err := p4Opened(ctx)
+	if err != nil {
+		if isNoMatch(err) {
+			return []ModifiedFile{}, nil
+		}
+		return nil, err
+	}
+
+	var changed []string
+	for _, record := range records {
+		if !unchangedMap[record.DepotFile] {
+			changed = append(changed, record.DepotFile)
+		}
+	}
+	headerOnly := findHeaderOnlyPackages(ctx, changed)
+
+	var hijacked []FileRecord
+	for _, record := range records {
+		if unchangedMap[record.DepotFile] || headerOnly[record.DepotFile] {
+			hijacked = append(hijacked, record)
+		}
+	}
+	resolveLocalPaths(ctx, hijacked)
+
+	files := []ModifiedFile{}
+	for _, record := range hijacked {
+		files = append(files, ModifiedFile{
+			Path:        recordLocalPath(record),
+			Action:      record.Action,
+			IsOpened:    true,
+			HeaderOnly:  headerOnly[record.DepotFile],
+			FileDetails: detailsFromRecord(record),
+		})
+	}
+
+	return files, nil
+}
+
+// hijackedLabel describes a hijacked file for lists, flagging re-saved packages
+func hijackedLabel(file ModifiedFile) string {
+	label := fmt.Sprintf("[CL %s] %s", file.ChangeLabel(), file.Path)
+	if file.HeaderOnly {
+		label += " (header only)"
+	}
+	return label
+}
+
+// filterFilesByPath keeps files under a local or depot (//...) path
+func filterFilesByPath(files []ModifiedFile, scope string) []ModifiedFile {
+	scope = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(scope), "..."), "/")
+
+	var kept []ModifiedFile
+	if strings.HasPrefix(scope, "//") {
+		for _, file := range files {
+			if file.DepotPath == scope || strings.HasPrefix(file.DepotPath, scope+"/") {
+				kept = append(kept, file)
+			}
+		}
+		return kept
+	}
+
+	if abs, err := filepath.Abs(scope); err == nil {
+		scope = abs
+	}
+	for _, file := range files {
+		rel, err := filepath.Rel(scope, file.Path)
+		if err == nil && !strings.HasPrefix(rel, "..") && !filepath.IsAbs(rel) {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// selectRevertScope asks which hijacked files to revert: all of them, those
+// under a path, those in one changelist, or a hand-picked subset. It returns
+// nil when cancelled or nothing matches.
+func selectRevertScope(files []ModifiedFile, reader *bufio.Reader) []ModifiedFile {
+	changes := make(map[string]int)
+	var changeOrder []string
+	for _, file := range files {
+		if changes[file.ChangeLabel()] == 0 {
+			changeOrder = append(changeOrder, file.ChangeLabel())
+		}
+		changes[file.ChangeLabel()]++
+	}
+
+	fmt.Println("\nWhich hijacked files should be reverted?")
+	fmt.Printf("  1. All %d hijacked file(s)\n", len(files))
+	fmt.Println("  2. Only those under a path")
+	fmt.Println("  3. Only those in one changelist")
+	fmt.Println("  4. Pick files from the list")
+	fmt.Println("  5. Cancel")
+	fmt.Print("\nEnter choice (1-5): ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	var selected []ModifiedFile
+	switch choice {
+	case "1":
+		selected = files
+	case "2":
+		fmt.Print("Path (local folder or depot path like //depot/Project/Content/...): ")
+		scope, _ := reader.ReadString('\n')
+		if strings.TrimSpace(scope) == "" {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+		selected = filterFilesByPath(files, scope)
+	case "3":
+		fmt.Println("\nChangelists with hijacked files:")
+		for _, change := range changeOrder {
+			fmt.Printf("  • %-10s %d file(s)\n", change, changes[change])
+		}
+		fmt.Print("Changelist: ")
+		change, _ := reader.ReadString('\n')
+		change = strings.TrimSpace(change)
+		for _, file := range files {
+			if file.ChangeLabel() == change {
+				selected = append(selected, file)
+			}
+		}
+	case "4":
+		fmt.Println()
+		for i, file := range files {
+			fmt.Printf("  %3d. %s\n", i+1, hijackedLabel(file))
+		}
+		fmt.Print("\nFiles to revert (e.g., 1,3,5-8 or 'all'): ")
+		input, _ := reader.ReadString('\n')
+		selected = parseFileSelection(input, files)
+	default:
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("No hijacked files match - nothing to revert.")
+	}
+	return selected
+}
+
+// revertHijackedFiles reverts hijacked (opened but unchanged) files within a
+// scope picked by the user, listing every file before asking to confirm
+func revertHijackedFiles(ctx context.Context, reader *bufio.Reader) error {
 	fmt.Println("\n🔄 Finding hijacked files (opened but unchanged)...")
 	fmt.Println("─────────────────────────────────────")
 
-	hijackedFiles, err := getUnchangedFiles()
+	hijackedFiles, err := getHijackedFiles(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to find hijacked files: %v", err)
 	}
@@ -197,18 +520,26 @@ func revertHijackedFiles() error {
 	fmt.Printf("Found %d hijacked file(s):\n", len(hijackedFiles))
 	for i, file := range hijackedFiles {
 		if i < 20 {
-			fmt.Printf("  • %s\n", file)
+			fmt.Printf("  • %s\n", hijackedLabel(file))
 		}
 	}
 	if len(hijackedFiles) > 20 {
 		fmt.Printf("  ... and %d more\n", len(hijackedFiles)-20)
 	}
 
-	fmt.Printf("\n⚠️  This will revert %d unchanged file(s)\n", len(hijackedFiles))
+	selected := selectRevertScope(hijackedFiles, reader)
+	if len(selected) == 0 {
+		return nil
+	}
+
+	fmt.Printf("\n⚠️  This will revert exactly these %d unchanged file(s):\n", len(selected))
+	for _, file := range selected {
+		fmt.Printf("  • %s\n", hijackedLabel(file))
+	}
+	fmt.Println("  Nothing else in your workspace is touched.")
 	fmt.Print("Proceed? (yes/no): ")
 
-	var response string
-	fmt.Scanln(&response)
+	response, _ := reader.ReadString('\n')
 	response = strings.TrimSpace(strings.ToLower(response))
 
 	if response != "yes" && response != "y" {
@@ -216,69 +547,109 @@ func revertHijackedFiles() error {
 		return nil
 	}
 
-	fmt.Println("\nReverting hijacked files...")
-
-	// Use p4 revert -a to revert unchanged files
-	cmd := exec.Command("p4", "revert", "-a")
-	output, err := cmd.CombinedOutput()
+	var unchangedPaths []string
+	var headerOnlyPaths []string
+	for _, file := range selected {
+		if file.HeaderOnly {
+			headerOnlyPaths = append(headerOnlyPaths, file.DepotPath)
+		} else {
+			unchangedPaths = append(unchangedPaths, file.DepotPath)
+		}
+	}
 
-	if err != nil && len(output) == 0 {
-		return fmt.Errorf("failed to revert files: %v", err)
+	// revert -a still skips any file edited since the check
+	fmt.Println("\nReverting hijacked files...")
+	if len(unchangedPaths) > 0 {
+		printBatchSummary(runP4Batch(ctx, unchangedPaths, "revert", "-a"))
 	}
 
-	outputStr := string(output)
-	fmt.Println(outputStr)
+	// p4 sees the new GUID or saved hash as a change, so revert -a would keep these
+	if len(headerOnlyPaths) > 0 {
+		fmt.Println("\nReverting re-saved packages (header-only changes)...")
+		printBatchSummary(runP4Batch(ctx, headerOnlyPaths, "revert"))
+	}
 
-	fmt.Println("\n✓ Done! Hijacked files have been reverted.")
+	fmt.Println("\n✓ Done! Selected hijacked files have been reverted.")
 	fmt.Println("  Your real changes remain checked out.")
 
 	return nil
 }
 
-// showHijackedStatus shows comparison of hijacked vs real changes
-func showHijackedStatus() error {
+// showHijackedStatus shows comparison of hijacked vs real changes, with text
+// files changed only in line endings or trailing whitespace in their own
+// bucket, and offers to revert those
+func showHijackedStatus(ctx context.Context, reader *bufio.Reader) error {
 	fmt.Println("\n📊 Hijacked Files Analysis")
 	fmt.Println("─────────────────────────────────────")
 
-	realChanges, hijacked, err := getRealChanges()
+	realChanges, hijacked, err := getRealChanges(ctx)
 	if err != nil {
 		return err
 	}
+	realChanges, cosmetic := splitCosmeticChanges(ctx, realChanges)
 
-	total := len(realChanges) + len(hijacked)
+	total := len(realChanges) + len(cosmetic) + len(hijacked)
+	if total == 0 {
+		fmt.Println("✓ No opened files.")
+		return nil
+	}
 
 	fmt.Printf("Total opened files:     %d\n", total)
 	fmt.Printf("  Real changes:         %d (%.0f%%)\n", len(realChanges), float64(len(realChanges))/float64(total)*100)
+	fmt.Printf("  Cosmetic only:        %d (%.0f%%) line endings/trailing whitespace\n", len(cosmetic), float64(len(cosmetic))/float64(total)*100)
 	fmt.Printf("  Hijacked (unchanged): %d (%.0f%%)\n", len(hijacked), float64(len(hijacked))/float64(total)*100)
 	fmt.Println("─────────────────────────────────────")
 
 	if len(realChanges) > 0 {
 		fmt.Println("\n✓ Real Changes:")
-		for i, file := range realChanges {
-			if i < 10 {
-				fmt.Printf("  • %s\n", file)
-			}
-		}
-		if len(realChanges) > 10 {
-			fmt.Printf("  ... and %d more\n", len(realChanges)-10)
-		}
+		printPathList(realChanges, 10)
+	}
+
+	if len(cosmetic) > 0 {
+		fmt.Println("\n🧹 Cosmetic Only (line endings/trailing whitespace):")
+		printPathList(cosmetic, 10)
 	}
 
 	if len(hijacked) > 0 {
 		fmt.Println("\n⚠️  Hijacked Files (unchanged):")
-		for i, file := range hijacked {
-			if i < 10 {
-				fmt.Printf("  • %s\n", file)
-			}
-		}
-		if len(hijacked) > 10 {
-			fmt.Printf("  ... and %d more\n", len(hijacked)-10)
-		}
+		printPathList(hijacked, 10)
+	}
+
+	if len(cosmetic) > 0 {
+		revertCosmeticChanges(ctx, cosmetic, reader)
 	}
 
 	return nil
 }
 
+// revertCosmeticChanges offers to revert files whose only changes are line
+// endings or trailing whitespace, listing every one of them first
+func revertCosmeticChanges(ctx context.Context, files []string, reader *bufio.Reader) {
+	fmt.Printf("\nRevert the %d cosmetic-only file(s) like hijacked files? (yes/no): ", len(files))
+	response, _ := reader.ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+	if response != "yes" && response != "y" {
+		fmt.Println("Skipped.")
+		return
+	}
+
+	fmt.Printf("\n⚠️  This will revert exactly these %d file(s), discarding their line ending and trailing whitespace edits:\n", len(files))
+	for _, file := range files {
+		fmt.Printf("  • %s\n", file)
+	}
+	fmt.Print("Proceed? (yes/no): ")
+	response, _ = reader.ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+	if response != "yes" && response != "y" {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	// p4 sees these as changed, so revert -a would keep them
+	fmt.Println("\nReverting cosmetic-only files...")
+	printBatchSummary(runP4Batch(ctx, files, "revert"))
+}
+
 // showHijackedMenu displays the quick hijacked file management menu
 func showHijackedMenu(reader *bufio.Reader) {
 	for {
@@ -295,14 +666,18 @@ func showHijackedMenu(reader *bufio.Reader) {
 
 		switch choice {
 		case "1":
-			err := showHijackedStatus()
+			ctx, endOperation := beginOperation()
+			err := showHijackedStatus(ctx, reader)
+			endOperation()
 			if err != nil {
 				fmt.Printf("\nError: %v\n", err)
 			}
 			fmt.Print("\nPress Enter to continue...")
 			reader.ReadString('\n')
 		case "2":
-			err := revertHijackedFiles()
+			ctx, endOperation := beginOperation()
+			err := revertHijackedFiles(ctx, reader)
+			endOperation()
 			if err != nil {
 				fmt.Printf("\nError: %v\n", err)
 			}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// openedRecords formats "p4 -ztag opened" output; files are "name@change"
+func openedRecords(files ...string) string {
+	output := ""
+	for _, file := range files {
+		name, change, _ := strings.Cut(file, "@")
+		output += fmt.Sprintf("... depotFile //depot/Game/%s\n... clientFile //ws/%s\n... action edit\n... change %s\n\n", name, name, change)
+	}
+	return output
+}
+
+var (
+	errExit1        = errors.New("exit status 1")
+	nothingOpened   = FakeResponse{Stderr: "File(s) not opened on this client.\n", Err: errExit1}
+	connectFailed   = FakeResponse{Stderr: "Perforce client error:\n\tConnect to server failed; check $P4PORT.\n", Err: errExit1}
+	openedWorkspace = FakeResponse{Stdout: openedRecords("Config/Game.ini@default", "Source/Hero.cpp@12", "Content/Hero.uasset@12")}
+)
+
+func TestGetRealChanges(t *testing.T) {
+	tests := []struct {
+		name         string
+		opened       FakeResponse
+		diff         FakeResponse
+		headerOnly   bool
+		wantReal     []string
+		wantHijacked []string
+		wantErr      bool
+	}{
+		{
+			name:         "unchanged files are hijacked",
+			opened:       openedWorkspace,
+			diff:         FakeResponse{Stdout: "... depotFile //depot/Game/Config/Game.ini\n\n"},
+			wantReal:     []string{"//depot/Game/Source/Hero.cpp", "//depot/Game/Content/Hero.uasset"},
+			wantHijacked: []string{"//depot/Game/Config/Game.ini"},
+		},
+		{
+			name:         "header-only packages are hijacked",
+			opened:       openedWorkspace,
+			diff:         FakeResponse{Stdout: "... depotFile //depot/Game/Config/Game.ini\n\n"},
+			headerOnly:   true,
+			wantReal:     []string{"//depot/Game/Source/Hero.cpp"},
+			wantHijacked: []string{"//depot/Game/Config/Game.ini", "//depot/Game/Content/Hero.uasset"},
+		},
+		{
+			name:   "nothing opened",
+			opened: nothingOpened,
+			diff:   nothingOpened,
+		},
+		{
+			name:    "server unreachable",
+			opened:  connectFailed,
+			wantErr: true,
+		},
+		{
+			name:    "diff fails",
+			opened:  openedWorkspace,
+			diff:    connectFailed,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, fake := setupFakeWorkspace(t)
+			fake.On("-ztag opened", tt.opened)
+			fake.On("-ztag diff -sr", tt.diff)
+			if tt.headerOnly {
+				scriptHeaderOnlyPackage(t, fake, root, "Content/Hero.uasset")
+			}
+
+			real, hijacked, err := getRealChanges(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getRealChanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(real) != fmt.Sprint(tt.wantReal) || fmt.Sprint(hijacked) != fmt.Sprint(tt.wantHijacked) {
+				t.Errorf("getRealChanges() = %v, %v, want %v, %v", real, hijacked, tt.wantReal, tt.wantHijacked)
+			}
+		})
+	}
+}
+
+func TestGetHijackedFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		opened      FakeResponse
+		headerOnly  bool
+		want        []string
+		wantChanges []string
+		wantErr     bool
+	}{
+		{
+			name:        "unchanged files",
+			opened:      openedWorkspace,
+			want:        []string{"//depot/Game/Config/Game.ini"},
+			wantChanges: []string{"default"},
+		},
+		{
+			name:        "header-only packages",
+			opened:      openedWorkspace,
+			headerOnly:  true,
+			want:        []string{"//depot/Game/Config/Game.ini", "//depot/Game/Content/Hero.uasset (header only)"},
+			wantChanges: []string{"default", "12"},
+		},
+		{
+			name:   "nothing opened",
+			opened: nothingOpened,
+		},
+		{
+			name:    "server unreachable",
+			opened:  connectFailed,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, fake := setupFakeWorkspace(t)
+			fake.On("-ztag opened", tt.opened)
+			fake.On("-ztag diff -sr", FakeResponse{Stdout: "... depotFile //depot/Game/Config/Game.ini\n\n"})
+			if tt.headerOnly {
+				scriptHeaderOnlyPackage(t, fake, root, "Content/Hero.uasset")
+			}
+
+			files, err := getHijackedFiles(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getHijackedFiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var changes []string
+			for _, file := range files {
+				changes = append(changes, file.Change)
+			}
+			if got := scannedDepotFiles(files); fmt.Sprint(got) != fmt.Sprint(tt.want) || fmt.Sprint(changes) != fmt.Sprint(tt.wantChanges) {
+				t.Errorf("getHijackedFiles() = %v in %v, want %v in %v", got, changes, tt.want, tt.wantChanges)
+			}
+		})
+	}
+}
+
+func TestGetSessionChanges(t *testing.T) {
+	_, fake := setupFakeWorkspace(t)
+	fake.On("-ztag opened", openedWorkspace)
+	fake.On("-ztag diff -sr", FakeResponse{Stdout: "... depotFile //depot/Game/Config/Game.ini\n\n"})
+
+	baseline := &Baseline{Files: []string{"//depot/Game/Source/Hero.cpp"}}
+	unchanged, edited, err := getSessionChanges(context.Background(), baseline)
+	if err != nil {
+		t.Fatalf("getSessionChanges() error = %v", err)
+	}
+
+	if fmt.Sprint(unchanged) != "[//depot/Game/Config/Game.ini]" || fmt.Sprint(edited) != "[//depot/Game/Content/Hero.uasset]" {
+		t.Errorf("getSessionChanges() = %v, %v, want the baseline's files left out", unchanged, edited)
+	}
+}
+
+func TestCaptureBaseline(t *testing.T) {
+	tests := []struct {
+		name        string
+		opened      FakeResponse
+		wantErr     bool
+		wantChanges map[string]string
+	}{
+		{
+			name:   "opened files",
+			opened: openedWorkspace,
+			wantChanges: map[string]string{
+				"//depot/Game/Config/Game.ini":     "default",
+				"//depot/Game/Source/Hero.cpp":     "12",
+				"//depot/Game/Content/Hero.uasset": "12",
+			},
+		},
+		{
+			name:        "nothing opened saves an empty baseline",
+			opened:      nothingOpened,
+			wantChanges: map[string]string{},
+		},
+		{
+			name:    "server unreachable saves nothing",
+			opened:  connectFailed,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempHome(t)
+			_, fake := setupFakeWorkspace(t)
+			fake.On("-ztag opened", tt.opened)
+			p4Info := &P4Info{ClientName: "ws", ServerAddr: "perforce:1666"}
+
+			err := captureBaseline(context.Background(), p4Info, "before editor")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("captureBaseline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				if _, err := os.Stat(getBaselinePath(p4Info)); !os.IsNotExist(err) {
+					t.Errorf("baseline file exists after a failed capture (stat error = %v)", err)
+				}
+				return
+			}
+
+			history, err := loadBaselineHistory(p4Info)
+			if err != nil {
+				t.Fatalf("loadBaselineHistory() error = %v", err)
+			}
+			if len(history.Snapshots) != 1 {
+				t.Fatalf("snapshots = %+v, want one", history.Snapshots)
+			}
+			if baseline := history.Snapshots[0]; baseline.Name != "before editor" || len(baseline.Files) != len(tt.wantChanges) || fmt.Sprint(baseline.Changes) != fmt.Sprint(tt.wantChanges) {
+				t.Errorf("baseline = %+v, want changes %v", baseline, tt.wantChanges)
+			}
+		})
+	}
+}
+
+func TestLoadBaselineHistoryMigratesLegacyBaseline(t *testing.T) {
+	home := useTempHome(t)
+	legacyPath := writeTestFile(t, home, ".p4chimari_baseline.json", `{"timestamp": "2024-05-01T10:00:00Z", "files": ["//depot/Game/Source/Hero.cpp"]}`)
+	p4Info := &P4Info{ClientName: "ws", ServerAddr: "perforce:1666"}
+
+	history, err := loadBaselineHistory(p4Info)
+	if err != nil {
+		t.Fatalf("loadBaselineHistory() error = %v", err)
+	}
+	if len(history.Snapshots) != 1 || history.Snapshots[0].Name != "imported 2024-05-01 10:00:00" || fmt.Sprint(history.Snapshots[0].Files) != "[//depot/Game/Source/Hero.cpp]" {
+		t.Fatalf("snapshots = %+v, want the old baseline imported", history.Snapshots)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("old baseline still in place (stat error = %v), want it renamed", err)
+	}
+
+	// Another workspace must not import it again
+	other, err := loadBaselineHistory(&P4Info{ClientName: "other", ServerAddr: "perforce:1666"})
+	if err != nil || len(other.Snapshots) != 0 {
+		t.Errorf("other workspace snapshots = %+v, %v, want none", other, err)
+	}
+	if reloaded, err := loadBaselineHistory(p4Info); err != nil || len(reloaded.Snapshots) != 1 {
+		t.Errorf("reloaded snapshots = %+v, %v, want the imported one kept", reloaded, err)
+	}
+}
+
+func TestDiffBaselines(t *testing.T) {
+	from := &Baseline{
+		Files:   []string{"//depot/a", "//depot/b", "//depot/c"},
+		Changes: map[string]string{"//depot/a": "default", "//depot/b": "10", "//depot/c": "10"},
+	}
+	to := &Baseline{
+		Files:   []string{"//depot/b", "//depot/c", "//depot/d"},
+		Changes: map[string]string{"//depot/b": "10", "//depot/c": "11", "//depot/d": "default"},
+	}
+
+	diff := diffBaselines(from, to)
+
+	if fmt.Sprint(diff.Added) != "[//depot/d]" || fmt.Sprint(diff.Removed) != "[//depot/a]" {
+		t.Errorf("diffBaselines() added %v, removed %v, want [//depot/d], [//depot/a]", diff.Added, diff.Removed)
+	}
+	if len(diff.Moved) != 1 || diff.Moved[0] != (BaselineMove{File: "//depot/c", From: "10", To: "11"}) {
+		t.Errorf("diffBaselines() moved %+v, want //depot/c from 10 to 11", diff.Moved)
+	}
+}
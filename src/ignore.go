@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Ignore presets applied on top of the workspace's P4IGNORE files
+const (
+	ignorePresetUE   = "ue"
+	ignorePresetNone = "none"
+)
+
+// ignorePreset selects the built-in rules used by scans (opt-in, off by default)
+var ignorePreset = ignorePresetNone
+
+// ueIgnorePatterns are build products and editor/IDE state that UE projects
+// never check in
+var ueIgnorePatterns = []string{
+	"Binaries/",
+	"DerivedDataCache/",
+	"Intermediate/",
+	"Saved/",
+	".vs/",
+	".vscode/",
+	".idea/",
+	"*.pdb",
+	"*.sln",
+	"*.suo",
+	"*.sdf",
+	"*.opensdf",
+	"*.VC.db",
+	"*.VC.opendb",
+	"*.xcworkspace",
+	".DS_Store",
+}
+
+// ignoreRule is one parsed line of a P4IGNORE file (or a preset)
+type ignoreRule struct {
+	Pattern string // the line as written
+	Source  string // "file:line" or the preset name
+	base    string // directory the rule is relative to
+	negate  bool   // "!pattern" re-includes files
+	dirOnly bool   // "pattern/" only matches directories
+	parts   []string
+	// A pattern without an inner slash matches a name at any depth
+	anywhere bool
+}
+
+// Label describes the rule for summaries
+func (r *ignoreRule) Label() string {
+	return fmt.Sprintf("%s (%s)", r.Pattern, r.Source)
+}
+
+// ============================================================================
+// PARSING
+// ============================================================================
+
+// parseIgnoreRule parses one P4IGNORE line; ok is false for blanks and comments
+func parseIgnoreRule(line string, base string, source string) (*ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, false
+	}
+
+	rule := &ignoreRule{Pattern: line, Source: source, base: base}
+	pattern := filepath.ToSlash(line)
+
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimLeft(pattern, "/")
+	if pattern == "" {
+		return nil, false
+	}
+
+	rule.parts = strings.Split(pattern, "/")
+	rule.anywhere = !anchored && len(rule.parts) == 1
+
+	return rule, true
+}
+
+// loadIgnoreFile parses an ignore file; a missing file has no rules
+func loadIgnoreFile(filePath string) []*ignoreRule {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []*ignoreRule
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		source := fmt.Sprintf("%s:%d", filePath, lineNum)
+		if rule, ok := parseIgnoreRule(scanner.Text(), filepath.Dir(filePath), source); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// presetIgnoreRules returns the rules of a built-in preset, relative to root
+func presetIgnoreRules(preset string, root string) []*ignoreRule {
+	if preset != ignorePresetUE {
+		return nil
+	}
+
+	var rules []*ignoreRule
+	for _, pattern := range ueIgnorePatterns {
+		if rule, ok := parseIgnoreRule(pattern, root, "UE preset"); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// ============================================================================
+// MATCHING
+// ============================================================================
+
+// IgnoreMatcher decides whether workspace files are ignored. Like p4, every
+// directory may hold ignore files whose rules apply below it; deeper files and
+// later lines win, and "!" rules re-include.
+type IgnoreMatcher struct {
+	root          string
+	caseSensitive bool
+	fileNames     []string
+	presets       []*ignoreRule
+	dirRules      map[string][]*ignoreRule
+}
+
+// newIgnoreMatcher builds a matcher for the current workspace
+func newIgnoreMatcher(ctx context.Context) *IgnoreMatcher {
+	matcher := &IgnoreMatcher{
+		caseSensitive: true,
+		fileNames:     p4IgnoreFileNames(ctx),
+		dirRules:      make(map[string][]*ignoreRule),
+	}
+
+	if view, err := getClientView(ctx); err == nil {
+		matcher.root = filepath.Clean(view.Root)
+		matcher.caseSensitive = view.CaseSensitive
+	} else if wd, err := os.Getwd(); err == nil {
+		matcher.root = wd
+	}
+	matcher.presets = presetIgnoreRules(ignorePreset, matcher.root)
+
+	return matcher
+}
+
+// p4IgnoreFileNames returns the ignore file names from P4IGNORE (environment,
+// p4 set or registry), defaulting to .p4ignore
+func p4IgnoreFileNames(ctx context.Context) []string {
+	value := os.Getenv("P4IGNORE")
+	if value == "" {
+		output, _ := runP4(ctx, "set", "-q", "P4IGNORE")
+		value = strings.TrimSpace(string(output))
+		value = strings.TrimPrefix(value, "P4IGNORE=")
+		if i := strings.Index(value, " ("); i >= 0 {
+			value = value[:i]
+		}
+	}
+
+	var names []string
+	for _, name := range filepath.SplitList(value) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		names = []string{".p4ignore"}
+	}
+	return names
+}
+
+// rulesIn returns the rules of the ignore files in dir, loading them once
+func (m *IgnoreMatcher) rulesIn(dir string) []*ignoreRule {
+	if rules, ok := m.dirRules[dir]; ok {
+		return rules
+	}
+
+	var rules []*ignoreRule
+	for _, name := range m.fileNames {
+		// An absolute P4IGNORE applies to the whole workspace
+		if filepath.IsAbs(name) {
+			continue
+		}
+		rules = append(rules, loadIgnoreFile(filepath.Join(dir, name))...)
+	}
+	m.dirRules[dir] = rules
+	return rules
+}
+
+// rulesFor returns every rule that applies to a file, lowest priority first
+func (m *IgnoreMatcher) rulesFor(filePath string) []*ignoreRule {
+	rules := append([]*ignoreRule{}, m.presets...)
+
+	for _, name := range m.fileNames {
+		if filepath.IsAbs(name) {
+			if _, ok := m.dirRules[name]; !ok {
+				m.dirRules[name] = loadIgnoreFile(name)
+			}
+			rules = append(rules, m.dirRules[name]...)
+		}
+	}
+
+	// Ignore files from the root down to the file's own directory
+	dir := filepath.Dir(filePath)
+	var dirs []string
+	for pathHasPrefix(dir, m.root, m.caseSensitive) {
+		dirs = append(dirs, dir)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rules = append(rules, m.rulesIn(dirs[i])...)
+	}
+
+	return rules
+}
+
+// Match returns the rule that ignores a file, or nil if it is not ignored
+func (m *IgnoreMatcher) Match(filePath string) *ignoreRule {
+	filePath = filepath.Clean(filePath)
+	if m.root == "" || !pathHasPrefix(filePath, m.root, m.caseSensitive) {
+		return nil
+	}
+
+	var decided *ignoreRule
+	for _, rule := range m.rulesFor(filePath) {
+		if m.ruleMatches(rule, filePath) {
+			decided = rule
+		}
+	}
+
+	if decided == nil || decided.negate {
+		return nil
+	}
+	return decided
+}
+
+// ruleMatches reports whether a rule matches a file or one of its parent
+// directories below the rule's base
+func (m *IgnoreMatcher) ruleMatches(rule *ignoreRule, filePath string) bool {
+	rel, err := filepath.Rel(rule.base, filePath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	components := strings.Split(filepath.ToSlash(rel), "/")
+
+	if rule.anywhere {
+		for i, component := range components {
+			isDir := i < len(components)-1
+			if (isDir || !rule.dirOnly) && m.globMatch(rule.parts[0], component) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Anchored patterns match the first k components, for any k
+	for k := 1; k <= len(components); k++ {
+		isDir := k < len(components)
+		if (isDir || !rule.dirOnly) && m.matchParts(rule.parts, components[:k]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchParts matches pattern components against path components; "**"
+// matches any number of components
+func (m *IgnoreMatcher) matchParts(parts []string, components []string) bool {
+	if len(parts) == 0 {
+		return len(components) == 0
+	}
+	if parts[0] == "**" {
+		for skip := 0; skip <= len(components); skip++ {
+			if m.matchParts(parts[1:], components[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(components) == 0 || !m.globMatch(parts[0], components[0]) {
+		return false
+	}
+	return m.matchParts(parts[1:], components[1:])
+}
+
+// globMatch matches one path component against a wildcard pattern
+func (m *IgnoreMatcher) globMatch(pattern string, name string) bool {
+	if !m.caseSensitive {
+		pattern = strings.ToLower(pattern)
+		name = strings.ToLower(name)
+	}
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// ============================================================================
+// FILTERING
+// ============================================================================
+
+// IgnoreStats counts the files suppressed by each ignore rule
+type IgnoreStats struct {
+	Total  int
+	ByRule map[string]int
+}
+
+// add counts one suppressed file
+func (s *IgnoreStats) add(rule *ignoreRule) {
+	if s.ByRule == nil {
+		s.ByRule = make(map[string]int)
+	}
+	s.Total++
+	s.ByRule[rule.Label()]++
+}
+
+// Print shows the suppression counts, busiest rule first
+func (s *IgnoreStats) Print(indent string) {
+	if s == nil || s.Total == 0 {
+		return
+	}
+
+	labels := make([]string, 0, len(s.ByRule))
+	for label := range s.ByRule {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if s.ByRule[labels[i]] != s.ByRule[labels[j]] {
+			return s.ByRule[labels[i]] > s.ByRule[labels[j]]
+		}
+		return labels[i] < labels[j]
+	})
+
+	fmt.Printf("%s🙈 %d file(s) suppressed by ignore rules:\n", indent, s.Total)
+	for _, label := range labels {
+		fmt.Printf("%s  %6d  %s\n", indent, s.ByRule[label], label)
+	}
+}
+
+// filterIgnoredAdds drops files that would be opened for add but match an
+// ignore rule. Edits and deletes of files already in the depot are kept,
+// since p4 ignore rules only affect adds.
+func filterIgnoredAdds(ctx context.Context, files []ModifiedFile) ([]ModifiedFile, *IgnoreStats) {
+	stats := &IgnoreStats{}
+	matcher := newIgnoreMatcher(ctx)
+
+	kept := []ModifiedFile{}
+	for _, file := range files {
+		if file.Action == "add" {
+			if rule := matcher.Match(file.Path); rule != nil {
+				stats.add(rule)
+				continue
+			}
+		}
+		kept = append(kept, file)
+	}
+
+	return kept, stats
+}
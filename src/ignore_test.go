@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		line         string
+		wantOK       bool
+		wantNegate   bool
+		wantDirOnly  bool
+		wantAnywhere bool
+		wantParts    int
+	}{
+		{"", false, false, false, false, 0},
+		{"# comment", false, false, false, false, 0},
+		{"/", false, false, false, false, 0},
+		{"*.tmp", true, false, false, true, 1},
+		{"Binaries/", true, false, true, true, 1},
+		{"/Saved", true, false, false, false, 1},
+		{"Saved/Logs", true, false, false, false, 2},
+		{"!keep.tmp", true, true, false, true, 1},
+		{"**/Cache/", true, false, true, false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			rule, ok := parseIgnoreRule(tt.line, "/ws", "test")
+			if ok != tt.wantOK {
+				t.Fatalf("parseIgnoreRule(%q) ok = %v, want %v", tt.line, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if rule.negate != tt.wantNegate || rule.dirOnly != tt.wantDirOnly || rule.anywhere != tt.wantAnywhere || len(rule.parts) != tt.wantParts {
+				t.Errorf("parseIgnoreRule(%q) = %+v", tt.line, rule)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcherMatch(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, root, ".p4ignore", "*.tmp\nBinaries/\n/Saved/Logs\n!keep.tmp\n**/Cache/\n")
+	writeTestFile(t, root, "Plugins/Tool/.p4ignore", "!*.tmp\n*.log\n")
+
+	tests := []struct {
+		name          string
+		path          string
+		caseSensitive bool
+		presets       bool
+		want          bool
+	}{
+		{"wildcard at any depth", "Content/a.tmp", true, false, true},
+		{"directory rule", "Game/Binaries/Win64/Game.exe", true, false, true},
+		{"directory rule does not match a file", "Game/Binaries", true, false, false},
+		{"anchored path", "Saved/Logs/Game.log", true, false, true},
+		{"anchored path elsewhere", "Game/Saved/Logs/Game.log", true, false, false},
+		{"negation re-includes", "Content/keep.tmp", true, false, false},
+		{"double star", "Content/Deep/Cache/x.bin", true, false, true},
+		{"deeper file wins", "Plugins/Tool/a.tmp", true, false, false},
+		{"deeper file adds rules", "Plugins/Tool/Build/out.log", true, false, true},
+		{"deeper rules stay below their folder", "Content/out.log", true, false, false},
+		{"not ignored", "Content/Hero.uasset", true, false, false},
+		{"case-insensitive server", "content/A.TMP", false, false, true},
+		{"case-sensitive server", "Content/a.TMP", true, false, false},
+		{"preset", "Intermediate/Build/a.obj", true, true, true},
+		{"preset off", "Intermediate/Build/a.obj", true, false, false},
+		{"preset keeps ThirdParty libraries", "Source/ThirdParty/Lib/x64/foo.lib", true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher := &IgnoreMatcher{
+				root:          root,
+				caseSensitive: tt.caseSensitive,
+				fileNames:     []string{".p4ignore"},
+				dirRules:      make(map[string][]*ignoreRule),
+			}
+			if tt.presets {
+				matcher.presets = presetIgnoreRules(ignorePresetUE, root)
+			}
+
+			rule := matcher.Match(filepath.Join(root, filepath.FromSlash(tt.path)))
+			if got := rule != nil; got != tt.want {
+				t.Errorf("Match(%q) = %v, want ignored %v", tt.path, rule, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcherOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, root, ".p4ignore", "*\n")
+
+	matcher := &IgnoreMatcher{root: root, caseSensitive: true, fileNames: []string{".p4ignore"}, dirRules: make(map[string][]*ignoreRule)}
+	if rule := matcher.Match(filepath.Join(filepath.Dir(root), "other.txt")); rule != nil {
+		t.Errorf("Match() outside the root = %v, want nil", rule)
+	}
+}
+
+func TestSuggestIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	matcher := &IgnoreMatcher{root: root, caseSensitive: true, fileNames: []string{".p4ignore"}, dirRules: make(map[string][]*ignoreRule)}
+	addPaths := []string{
+		filepath.Join(root, "Saved", "Logs", "Game.log"),
+		filepath.Join(root, "Saved", "Config", "Game.ini"),
+		filepath.Join(root, "ThirdParty", "Lib", "zlib.obj"),
+		filepath.Join(root, "Binaries", "Game.pdb"),
+	}
+
+	var patterns []string
+	for _, suggestion := range suggestIgnoreRules(matcher, root, addPaths) {
+		patterns = append(patterns, suggestion.Pattern)
+	}
+	if fmt.Sprint(patterns) != "[Saved/ *.log Binaries/ *.pdb]" {
+		t.Errorf("suggestIgnoreRules() = %v, want [Saved/ *.log Binaries/ *.pdb] (checked-in .obj files are never suggested)", patterns)
+	}
+}
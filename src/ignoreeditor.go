@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ueIgnoreTemplate is the recommended P4IGNORE file for UE projects
+const ueIgnoreTemplate = `# P4IGNORE rules for Unreal Engine projects (generated by p4chimari)
+# Make p4 use this file with: p4 set P4IGNORE=.p4ignore
+
+# Build products (compiler output only lives under these folders; ThirdParty
+# libraries ship checked-in .lib/.obj files, so those are not ignored globally)
+Binaries/
+Intermediate/
+*.pdb
+*.ilk
+*.exp
+
+# Caches, logs, autosaves and local settings
+DerivedDataCache/
+Saved/
+
+# IDE and project files generated by UE
+.vs/
+.vscode/
+.idea/
+*.sln
+*.suo
+*.sdf
+*.opensdf
+*.VC.db
+*.VC.opendb
+*.xcworkspace
+*.xcodeproj
+
+# OS clutter
+.DS_Store
+Thumbs.db
+`
+
+// junkDirNames are directory names that hold generated content in UE projects
+var junkDirNames = []string{"Binaries", "Intermediate", "Saved", "DerivedDataCache", ".vs", ".vscode", ".idea"}
+
+// junkExtensions are file extensions that are almost never meant to be added
+var junkExtensions = []string{".pdb", ".ilk", ".exp", ".tmp", ".log", ".bak", ".suo", ".sdf", ".opensdf"}
+
+// ignoreSuggestion is a candidate rule and the reported files it would hide
+type ignoreSuggestion struct {
+	Pattern string
+	Files   []string
+}
+
+// workspaceIgnoreFile returns the P4IGNORE file at the workspace root
+func workspaceIgnoreFile(ctx context.Context) string {
+	name := p4IgnoreFileNames(ctx)[0]
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	root := "."
+	if view, err := getClientView(ctx); err == nil {
+		root = view.Root
+	}
+	return filepath.Join(root, name)
+}
+
+// editP4Ignore lets the user hide junk adds by appending rules to the
+// workspace's ignore file, previewing which reported files each rule hides.
+// addPaths are the local paths currently reported for add.
+func editP4Ignore(ctx context.Context, addPaths []string, reader *bufio.Reader) {
+	ignoreFile := workspaceIgnoreFile(ctx)
+
+	fmt.Println("\n─────────────────────────────────────")
+	fmt.Println("EDIT IGNORE RULES")
+	fmt.Println("─────────────────────────────────────")
+	fmt.Printf("Ignore file: %s\n", ignoreFile)
+
+	if _, err := os.Stat(ignoreFile); os.IsNotExist(err) {
+		if generateIgnoreFile(ctx, ignoreFile, addPaths, reader) {
+			return
+		}
+	}
+
+	if len(addPaths) == 0 {
+		fmt.Println("\nNo files are reported for add - nothing to hide.")
+		return
+	}
+
+	matcher := newIgnoreMatcher(ctx)
+	base := filepath.Dir(ignoreFile)
+	suggestions := suggestIgnoreRules(matcher, base, addPaths)
+
+	fmt.Printf("\n%d file(s) are reported for add.\n", len(addPaths))
+	if len(suggestions) > 0 {
+		fmt.Println("\nSuggested rules:")
+		for i, suggestion := range suggestions {
+			fmt.Printf("  %d. %-24s hides %d file(s)\n", i+1, suggestion.Pattern, len(suggestion.Files))
+		}
+	} else {
+		fmt.Println("\nNo obvious junk found among the reported adds.")
+	}
+
+	fmt.Println()
+	fmt.Println("Enter suggestion number(s) (e.g. 1,3), or type a pattern (e.g. *.tmp, Saved/, /Build/)")
+	fmt.Print("Press Enter to cancel: ")
+
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(input)
+	if input == "" {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	patterns := selectIgnorePatterns(input, suggestions)
+	if len(patterns) == 0 {
+		fmt.Println("No valid rules entered.")
+		return
+	}
+
+	// Preview what the new rules would hide before touching the file
+	var rules []*ignoreRule
+	for _, pattern := range patterns {
+		if rule, ok := parseIgnoreRule(pattern, base, "new"); ok {
+			rules = append(rules, rule)
+		}
+	}
+	hidden := filesMatchingRules(matcher, rules, addPaths)
+
+	fmt.Printf("\nPreview - these rule(s) would hide %d of %d reported file(s):\n", len(hidden), len(addPaths))
+	for _, pattern := range patterns {
+		fmt.Printf("  + %s\n", pattern)
+	}
+	fmt.Println()
+	for i, file := range hidden {
+		if i < 15 {
+			fmt.Printf("    %s\n", file)
+		}
+	}
+	if len(hidden) > 15 {
+		fmt.Printf("    ... and %d more\n", len(hidden)-15)
+	}
+
+	fmt.Printf("\nAppend to %s? (y/n): ", ignoreFile)
+	confirm, _ := reader.ReadString('\n')
+	confirm = strings.TrimSpace(strings.ToLower(confirm))
+	if confirm != "y" && confirm != "yes" {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	if err := appendIgnoreRules(ignoreFile, patterns); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("✓ Added %d rule(s) - rescan to see the result\n", len(patterns))
+}
+
+// generateIgnoreFile offers to create the ignore file from the UE template.
+// It returns true when the file was written.
+func generateIgnoreFile(ctx context.Context, ignoreFile string, addPaths []string, reader *bufio.Reader) bool {
+	matcher := newIgnoreMatcher(ctx)
+	var rules []*ignoreRule
+	for _, line := range strings.Split(ueIgnoreTemplate, "\n") {
+		if rule, ok := parseIgnoreRule(line, filepath.Dir(ignoreFile), "template"); ok {
+			rules = append(rules, rule)
+		}
+	}
+	hidden := filesMatchingRules(matcher, rules, addPaths)
+
+	fmt.Println("\nNo ignore file exists yet.")
+	fmt.Printf("The recommended UE template has %d rule(s) and would hide %d of %d reported file(s).\n", len(rules), len(hidden), len(addPaths))
+	fmt.Print("Generate it now? (y/n): ")
+
+	confirm, _ := reader.ReadString('\n')
+	confirm = strings.TrimSpace(strings.ToLower(confirm))
+	if confirm != "y" && confirm != "yes" {
+		return false
+	}
+
+	if err := os.WriteFile(ignoreFile, []byte(ueIgnoreTemplate), 0644); err != nil {
+		fmt.Printf("Error: failed to write %s: %v\n", ignoreFile, err)
+		return false
+	}
+
+	fmt.Printf("✓ Created %s\n", ignoreFile)
+	if os.Getenv("P4IGNORE") == "" {
+		if output, _ := runP4(ctx, "set", "-q", "P4IGNORE"); strings.TrimSpace(string(output)) == "" {
+			fmt.Printf("  Tip: run 'p4 set P4IGNORE=%s' so p4 itself honours it too\n", filepath.Base(ignoreFile))
+		}
+	}
+	return true
+}
+
+// suggestIgnoreRules proposes rules for junk directories and extensions
+// found among the reported adds, most effective first
+func suggestIgnoreRules(matcher *IgnoreMatcher, base string, addPaths []string) []ignoreSuggestion {
+	var candidates []string
+	seen := make(map[string]bool)
+	addCandidate := func(pattern string) {
+		if !seen[pattern] {
+			seen[pattern] = true
+			candidates = append(candidates, pattern)
+		}
+	}
+
+	for _, addPath := range addPaths {
+		components := strings.Split(filepath.ToSlash(addPath), "/")
+		for _, component := range components[:len(components)-1] {
+			for _, junkDir := range junkDirNames {
+				if strings.EqualFold(component, junkDir) {
+					addCandidate(junkDir + "/")
+				}
+			}
+		}
+
+		ext := filepath.Ext(addPath)
+		for _, junkExt := range junkExtensions {
+			if strings.EqualFold(ext, junkExt) {
+				addCandidate("*" + junkExt)
+			}
+		}
+	}
+
+	var suggestions []ignoreSuggestion
+	for _, pattern := range candidates {
+		rule, ok := parseIgnoreRule(pattern, base, "new")
+		if !ok {
+			continue
+		}
+		files := filesMatchingRules(matcher, []*ignoreRule{rule}, addPaths)
+		if len(files) > 0 {
+			suggestions = append(suggestions, ignoreSuggestion{Pattern: pattern, Files: files})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return len(suggestions[i].Files) > len(suggestions[j].Files)
+	})
+	return suggestions
+}
+
+// selectIgnorePatterns turns "1,3" into suggestion patterns; anything that
+// is not a list of numbers is taken as comma-separated literal patterns
+func selectIgnorePatterns(input string, suggestions []ignoreSuggestion) []string {
+	parts := strings.Split(input, ",")
+
+	var patterns []string
+	for _, part := range parts {
+		index, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			patterns = nil
+			break
+		}
+		if index >= 1 && index <= len(suggestions) {
+			patterns = append(patterns, suggestions[index-1].Pattern)
+		}
+	}
+	if patterns != nil {
+		return patterns
+	}
+
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			if _, err := strconv.Atoi(part); err != nil {
+				patterns = append(patterns, part)
+			}
+		}
+	}
+	return patterns
+}
+
+// filesMatchingRules returns the paths the rules would ignore
+func filesMatchingRules(matcher *IgnoreMatcher, rules []*ignoreRule, paths []string) []string {
+	var matched []string
+	for _, path := range paths {
+		var decided *ignoreRule
+		for _, rule := range rules {
+			if matcher.ruleMatches(rule, filepath.Clean(path)) {
+				decided = rule
+			}
+		}
+		if decided != nil && !decided.negate {
+			matched = append(matched, path)
+		}
+	}
+	return matched
+}
+
+// appendIgnoreRules appends rules to an ignore file, creating it if needed
+func appendIgnoreRules(ignoreFile string, patterns []string) error {
+	existing, err := os.ReadFile(ignoreFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %v", ignoreFile, err)
+	}
+
+	var text strings.Builder
+	if len(existing) > 0 {
+		if !strings.HasSuffix(string(existing), "\n") {
+			text.WriteString("\n")
+		}
+		text.WriteString("\n")
+	}
+	text.WriteString(fmt.Sprintf("# Added by p4chimari %s\n", time.Now().Format("2006-01-02")))
+	for _, pattern := range patterns {
+		text.WriteString(pattern + "\n")
+	}
+
+	file, err := os.OpenFile(ignoreFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", ignoreFile, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(text.String()); err != nil {
+		return fmt.Errorf("failed to write %s: %v", ignoreFile, err)
+	}
+	return nil
+}
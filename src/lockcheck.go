@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OtherOpen is another user's workspace that has a file opened
+type OtherOpen struct {
+	User   string
+	Client string
+	Change string
+	Action string
+	Locked bool // holds an exclusive (+l) open or a "p4 lock"
+}
+
+// CheckoutConflict is why a selected file can't be checked out cleanly
+type CheckoutConflict struct {
+	Exclusive []OtherOpen // others holding the file exclusively
+	HaveRev   int
+	HeadRev   int
+}
+
+// OutOfDate reports whether the workspace is behind the head revision
+func (c CheckoutConflict) OutOfDate() bool {
+	return c.HaveRev > 0 && c.HaveRev < c.HeadRev
+}
+
+// isExclusiveType reports whether a file type has the +l (exclusive open) modifier
+func isExclusiveType(fileType string) bool {
+	_, modifiers, _ := strings.Cut(fileType, "+")
+	return strings.Contains(modifiers, "l")
+}
+
+// otherOpensFromRecord decodes the numbered otherOpen, otherAction and
+// otherChange fields of a tagged fstat record. The otherLock fields are
+// numbered on their own, so lock holders are matched to opens by user@client
+// (p4 only lets a workspace lock files it has open).
+func otherOpensFromRecord(r P4Record) []OtherOpen {
+	exclusive := isExclusiveType(r["headType"])
+
+	holders := make(map[string]bool)
+	for i := 0; ; i++ {
+		holder, ok := r["otherLock"+strconv.Itoa(i)]
+		if !ok {
+			break
+		}
+		holders[strings.ToLower(holder)] = true
+	}
+
+	var opens []OtherOpen
+	for i := 0; ; i++ {
+		suffix := strconv.Itoa(i)
+		who, ok := r["otherOpen"+suffix]
+		if !ok {
+			break
+		}
+
+		user, client, _ := strings.Cut(who, "@")
+		opens = append(opens, OtherOpen{
+			User:   user,
+			Client: client,
+			Change: r["otherChange"+suffix],
+			Action: r["otherAction"+suffix],
+			Locked: exclusive || holders[strings.ToLower(who)],
+		})
+	}
+	return opens
+}
+
+// checkoutDepotPath is the depot file a checkout opens: the source of a
+// move, nothing for a file that is not in the depot yet
+func checkoutDepotPath(ctx context.Context, file DirtyFile) string {
+	if file.Action == "add" {
+		return ""
+	}
+	if file.MovedFrom != "" {
+		if view, err := getClientView(ctx); err == nil {
+			if depotPath, ok := view.LocalToDepot(file.MovedFrom); ok {
+				return depotPath
+			}
+		}
+	}
+	return file.DepotPath
+}
+
+// ============================================================================
+// PRE-CHECKOUT CHECK
+// ============================================================================
+
+// findCheckoutConflicts checks the selected files with batched "p4 fstat"
+// calls and returns, by index, those opened exclusively by someone else or
+// behind the head revision. Moves are checked at their source path; files
+// not in the depot yet are never conflicts.
+func findCheckoutConflicts(ctx context.Context, files []DirtyFile) map[int]CheckoutConflict {
+	conflicts := make(map[int]CheckoutConflict)
+
+	byDepot := make(map[string][]int)
+	var depotPaths []string
+	for i, file := range files {
+		depotPath := checkoutDepotPath(ctx, file)
+		if depotPath == "" {
+			continue
+		}
+		if _, ok := byDepot[depotPath]; !ok {
+			depotPaths = append(depotPaths, depotPath)
+		}
+		byDepot[depotPath] = append(byDepot[depotPath], i)
+	}
+
+	for _, chunk := range chunkFiles(depotPaths, batchMaxFiles, batchMaxBytes) {
+		if ctx.Err() != nil {
+			break
+		}
+
+		records, _ := runP4TaggedArgFile(ctx, chunk, "fstat")
+		for _, r := range records {
+			conflict := CheckoutConflict{HaveRev: r.Int("haveRev"), HeadRev: r.Int("headRev")}
+			for _, open := range otherOpensFromRecord(r) {
+				if open.Locked {
+					conflict.Exclusive = append(conflict.Exclusive, open)
+				}
+			}
+			if len(conflict.Exclusive) == 0 && !conflict.OutOfDate() {
+				continue
+			}
+			for _, index := range byDepot[r["depotFile"]] {
+				conflicts[index] = conflict
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// filterCheckoutConflicts shows which selected files are locked by others or
+// out of date and lets the user skip them, check out everything anyway, or
+// cancel. It returns the files to check out (nil when cancelled).
+func filterCheckoutConflicts(ctx context.Context, files []DirtyFile, reader *bufio.Reader) []DirtyFile {
+	fmt.Println("\nChecking for exclusive locks and out-of-date files (p4 fstat)...")
+	conflicts := findCheckoutConflicts(ctx, files)
+	if ctx.Err() != nil {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+	if len(conflicts) == 0 {
+		fmt.Println("  ✓ No conflicts")
+		return files
+	}
+
+	locked, outOfDate := 0, 0
+	for i, file := range files {
+		conflict, ok := conflicts[i]
+		if !ok {
+			continue
+		}
+		if len(conflict.Exclusive) > 0 {
+			locked++
+			fmt.Printf("\n  🔒 %s\n", file.Path)
+			for _, open := range conflict.Exclusive {
+				fmt.Printf("       opened exclusively by %s on %s (CL %s, %s)\n", open.User, open.Client, open.Change, open.Action)
+			}
+		}
+		if conflict.OutOfDate() {
+			outOfDate++
+			if len(conflict.Exclusive) == 0 {
+				fmt.Printf("\n  ⏳ %s\n", file.Path)
+			}
+			fmt.Printf("       out of date: have #%d, head #%d - sync before editing\n", conflict.HaveRev, conflict.HeadRev)
+		}
+	}
+
+	fmt.Println("\n─────────────────────────────────────")
+	fmt.Printf("%d file(s) locked by someone else, %d out of date, %d of %d selected file(s) are clear.\n", locked, outOfDate, len(files)-len(conflicts), len(files))
+	fmt.Println("  s. Skip these and check out the rest (default)")
+	fmt.Println("  a. Check out all selected files anyway")
+	fmt.Println("  c. Cancel")
+	fmt.Print("\nEnter choice (s/a/c): ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(strings.ToLower(choice))
+
+	switch choice {
+	case "", "s":
+		var rest []DirtyFile
+		for i, file := range files {
+			if _, ok := conflicts[i]; !ok {
+				rest = append(rest, file)
+			}
+		}
+		if len(rest) == 0 {
+			fmt.Println("Nothing left to check out.")
+		}
+		return rest
+	case "a":
+		return files
+	default:
+		fmt.Println("Cancelled.")
+		return nil
+	}
+}
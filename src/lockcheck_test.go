@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestOtherOpensFromRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		record P4Record
+		want   string
+	}{
+		{
+			name: "lock index differs from open index",
+			record: P4Record{
+				"headType":     "binary",
+				"otherOpen0":   "ana@ana-ws",
+				"otherAction0": "edit",
+				"otherChange0": "default",
+				"otherOpen1":   "ben@ben-ws",
+				"otherAction1": "edit",
+				"otherChange1": "12",
+				"otherLock":    "",
+				"otherLock0":   "ben@ben-ws",
+			},
+			want: "[{ana ana-ws default edit false} {ben ben-ws 12 edit true}]",
+		},
+		{
+			name: "exclusive type locks every open",
+			record: P4Record{
+				"headType":     "binary+l",
+				"otherOpen0":   "ana@ana-ws",
+				"otherAction0": "edit",
+				"otherChange0": "7",
+			},
+			want: "[{ana ana-ws 7 edit true}]",
+		},
+		{
+			name:   "not opened elsewhere",
+			record: P4Record{"headType": "text"},
+			want:   "[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(otherOpensFromRecord(tt.record)); got != tt.want {
+				t.Errorf("otherOpensFromRecord() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindCheckoutConflictsMoveSource(t *testing.T) {
+	root, fake := setupFakeWorkspace(t)
+	fake.On("-ztag -x - fstat", FakeResponse{Stdout: "... depotFile //depot/Game/Content/Old.uasset\n... headType binary\n... haveRev 3\n... headRev 3\n" +
+		"... otherOpen0 ana@ana-ws\n... otherAction0 edit\n... otherChange0 12\n... otherLock \n... otherLock0 ana@ana-ws\n\n"})
+
+	files := []DirtyFile{{
+		Path:        filepath.Join(root, "Content", "New.uasset"),
+		Action:      "move",
+		MovedFrom:   filepath.Join(root, "Content", "Old.uasset"),
+		FileDetails: FileDetails{DepotPath: "//depot/Game/Content/New.uasset"},
+	}}
+	conflicts := findCheckoutConflicts(context.Background(), files)
+
+	if len(fake.Calls) != 1 || fake.Calls[0].Stdin != "//depot/Game/Content/Old.uasset\n" {
+		t.Fatalf("calls = %+v, want one fstat of the move source", fake.Calls)
+	}
+	if conflict, ok := conflicts[0]; !ok || len(conflict.Exclusive) != 1 || conflict.Exclusive[0].User != "ana" {
+		t.Errorf("conflicts = %+v, want the move reported as locked by ana", conflicts)
+	}
+}
@@ -2,17 +2,18 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
 type DirtyFile struct {
-	Path   string
-	Action string
+	Path      string
+	Action    string
+	MovedFrom string // local path a "move" entry was renamed from
+	FileDetails
 }
 
 type P4Info struct {
@@ -22,6 +23,7 @@ type P4Info struct {
 	ClientRoot   string
 	ServerAddr   string
 	ServerUptime string
+	CaseHandling string
 	CurrentDir   string
 }
 
@@ -35,15 +37,26 @@ func main() {
 	clearScreen()
 	printHeader()
 
+	// Load config
+	config, _ := loadConfig()
+	p4Runner = &CLIRunner{Timeout: config.P4Timeout()}
+	scanConcurrency = config.ScanWorkers()
+	detectMode = config.ScanDetectMode()
+	ignorePreset = config.ScanIgnorePreset()
+
+	// Ctrl+C cancels the running operation and returns to the menu
+	installInterruptHandler()
+	ctx := context.Background()
+
 	// Check if p4 is available
-	if !isP4Available() {
+	if !isP4Available(ctx) {
 		fmt.Println("❌ Status: NOT CONNECTED")
 		fmt.Println("Error: p4 command not found. Please ensure Perforce CLI is installed and in PATH.")
 		return
 	}
 
 	// Get detailed P4 connection info
-	p4Info, err := getP4Info()
+	p4Info, err := getP4Info(ctx)
 	if err != nil {
 		fmt.Println("❌ Status: NOT CONNECTED")
 		fmt.Printf("Error: Unable to connect to P4.\n%v\n", err)
@@ -51,7 +64,7 @@ func main() {
 	}
 
 	// Check if current directory is under workspace root
-	if !strings.HasPrefix(strings.ToLower(p4Info.CurrentDir), strings.ToLower(p4Info.ClientRoot)) {
+	if !pathHasPrefix(p4Info.CurrentDir, p4Info.ClientRoot, p4Info.CaseHandling == "sensitive") {
 		fmt.Println("⚠ WARNING: Not in workspace directory!")
 		fmt.Printf("  Current Dir: %s\n", p4Info.CurrentDir)
 		fmt.Printf("  Workspace Root: %s\n", p4Info.ClientRoot)
@@ -79,13 +92,29 @@ func main() {
 	fmt.Printf("  Current Dir:     %s\n", p4Info.CurrentDir)
 	fmt.Println("─────────────────────────────────────")
 
-	// Load config
-	config, _ := loadConfig()
+	// Optional background watcher that makes repeat scans near-instant
+	if config.WatchWorkspace {
+		if err := startActiveWatcher(p4Info.ClientRoot); err != nil {
+			fmt.Printf("⚠ %v - scans will run in full\n", err)
+		} else {
+			fmt.Println("👁 Watching workspace for changes")
+		}
+	}
+
+	// Optional poller that warns when someone submits over our opened files
+	if config.PollSubmits {
+		if err := startActivePoller(config.PollInterval()); err != nil {
+			fmt.Printf("⚠ %v\n", err)
+		} else {
+			fmt.Printf("📡 Checking for new submits to your opened files every %s\n", config.PollInterval())
+		}
+	}
 
 	// Single-level main menu
 	reader := bufio.NewReader(os.Stdin)
 
 	for {
+		printSubmitAlerts(5)
 		fmt.Println("\n─────────────────────────────────────")
 		fmt.Println("MAIN MENU")
 		fmt.Println("─────────────────────────────────────")
@@ -95,15 +124,20 @@ func main() {
 		fmt.Println("  4. 🎯 Show hijacked files - See which opened files have NO changes")
 		fmt.Println("  5. 🧹 Auto-revert unchanged files - Clean up hijacked files")
 		fmt.Println("  6. 🔍 Scan ALL modified files & force sync selected")
-		fmt.Println("  7. Exit")
-		fmt.Print("\nEnter choice (1-7): ")
+		fmt.Println("  7. 📸 Editor session - Baseline, launch UE, revert what it opened needlessly")
+		fmt.Println("  8. 🔀 Resolve files - Accept yours/theirs/merged")
+		fmt.Println("  9. ⚙️  Settings & maintenance")
+		fmt.Println("  10. Exit")
+		fmt.Print("\nEnter choice (1-10): ")
 
 		choice, _ := reader.ReadString('\n')
 		choice = strings.TrimSpace(choice)
 
 		switch choice {
 		case "1":
-			showViewChanges(p4Info)
+			opCtx, endOperation := beginOperation()
+			showViewChanges(opCtx, p4Info)
+			endOperation()
 			fmt.Print("\nPress Enter to continue...")
 			reader.ReadString('\n')
 		case "2":
@@ -116,31 +150,51 @@ func main() {
 			}
 
 			// Scan and show results
-			scanAndShowFiles(selectedFolders, reader)
+			opCtx, endOperation := beginOperation()
+			scanAndShowFiles(opCtx, selectedFolders, reader)
+			endOperation()
 		case "3":
 			projectPath := filepath.Join(p4Info.ClientRoot, "Project")
-			reconcileFilesInFolders([]string{projectPath})
+			opCtx, endOperation := beginOperation()
+			reconcileFilesInFolders(opCtx, []string{projectPath})
+			endOperation()
 			fmt.Print("\nPress Enter to continue...")
 			reader.ReadString('\n')
 		case "4":
-			err := showHijackedStatus()
+			opCtx, endOperation := beginOperation()
+			err := showHijackedStatus(opCtx, reader)
+			endOperation()
 			if err != nil {
 				fmt.Printf("Error: %v\n", err)
 			}
 			fmt.Print("\nPress Enter to continue...")
 			reader.ReadString('\n')
 		case "5":
-			err := revertHijackedFiles()
+			opCtx, endOperation := beginOperation()
+			err := revertHijackedFiles(opCtx, reader)
+			endOperation()
 			if err != nil {
 				fmt.Printf("Error: %v\n", err)
 			}
 			fmt.Print("\nPress Enter to continue...")
 			reader.ReadString('\n')
 		case "6":
-			ShowModifiedFilesAndRevert(p4Info, reader)
+			opCtx, endOperation := beginOperation()
+			ShowModifiedFilesAndRevert(opCtx, p4Info, reader)
+			endOperation()
 			fmt.Print("\nPress Enter to continue...")
 			reader.ReadString('\n')
 		case "7":
+			showEditorSessionMenu(p4Info, config, reader)
+		case "8":
+			opCtx, endOperation := beginOperation()
+			showResolveScreen(opCtx, reader)
+			endOperation()
+		case "9":
+			showSettingsMenu(p4Info, config, reader)
+		case "10":
+			stopActiveWatcher()
+			stopActivePoller()
 			fmt.Println("Exiting.")
 			return
 		default:
@@ -149,21 +203,24 @@ func main() {
 	}
 }
 
-func scanAndShowFiles(selectedFolders []string, reader *bufio.Reader) {
+func scanAndShowFiles(ctx context.Context, selectedFolders []string, reader *bufio.Reader) {
 	// Scan workspace for changes
 	fmt.Println("\nScanning workspace for changes...")
 	fmt.Println("─────────────────────────────────────")
 
 	fmt.Printf("→ Checking pending changes (p4 opened)...\n")
-	pendingFiles, _ := getPendingFiles()
+	pendingFiles, _ := getPendingFiles(ctx)
 	fmt.Printf("  ✓ Found %d file(s) already checked out\n", len(pendingFiles))
 
 	fmt.Printf("→ Scanning for modified files in selected folders...\n")
 	for _, folder := range selectedFolders {
 		fmt.Printf("  - %s\n", folder)
 	}
-	dirtyFiles, err := findDirtyFilesInFolders(selectedFolders, true)
-	if err != nil {
+	dirtyFiles, err := findDirtyFilesInFolders(ctx, selectedFolders, true)
+	cancelled := err != nil && isInterrupted(ctx, err)
+	if cancelled {
+		fmt.Printf("  ⛔ Scan CANCELLED (%v) - showing %d file(s) found so far\n", err, len(dirtyFiles))
+	} else if err != nil {
 		fmt.Printf("  ✗ Error: %v\n", err)
 	} else {
 		fmt.Printf("  ✓ Found %d file(s) modified but not checked out\n", len(dirtyFiles))
@@ -193,7 +250,7 @@ func scanAndShowFiles(selectedFolders []string, reader *bufio.Reader) {
 		fmt.Printf("  %d file(s) need attention\n", len(dirtyFiles))
 		for i, file := range dirtyFiles {
 			if i < 10 {
-				fmt.Printf("    • [%s] %s\n", file.Action, file.Path)
+				fmt.Printf("    • [%s] %-14s %s\n", file.Action, file.TypeLabel(), movePathLabel(file.Path, file.MovedFrom))
 			}
 		}
 		if len(dirtyFiles) > 10 {
@@ -201,37 +258,53 @@ func scanAndShowFiles(selectedFolders []string, reader *bufio.Reader) {
 		}
 	}
 
+	// The operation is over once cancelled; go back to the menu
+	if ctx.Err() != nil {
+		fmt.Print("\nPress Enter to continue...")
+		reader.ReadString('\n')
+		return
+	}
+
 	// Actions menu
 	fmt.Println("\n─────────────────────────────────────")
 	fmt.Println("Actions:")
-	fmt.Println("  1. Filter by action (add/edit/delete)")
+	fmt.Println("  1. Filter by action (add/edit/delete/move)")
 	fmt.Println("  2. Checkout selected files")
 	fmt.Println("  3. Reconcile all in these folders")
 	fmt.Println("  4. Revert files")
-	fmt.Println("  5. Back to main menu")
-	fmt.Print("\nEnter choice (1-5): ")
+	fmt.Println("  5. Hide junk adds with ignore rules (.p4ignore)")
+	fmt.Println("  6. Back to main menu")
+	fmt.Print("\nEnter choice (1-6): ")
 
 	choice, _ := reader.ReadString('\n')
 	choice = strings.TrimSpace(choice)
 
 	switch choice {
 	case "1":
-		filterByAction(dirtyFiles, reader)
+		filterByAction(ctx, dirtyFiles, reader)
 	case "2":
 		if len(dirtyFiles) > 0 {
-			selectAndCheckoutFiles(dirtyFiles)
+			selectAndCheckoutFiles(ctx, dirtyFiles)
 		} else {
 			fmt.Println("No files to checkout.")
 		}
 	case "3":
-		reconcileFilesInFolders(selectedFolders)
+		reconcileFilesInFolders(ctx, selectedFolders)
 	case "4":
 		if len(dirtyFiles) > 0 {
-			revertFiles(dirtyFiles, reader)
+			revertFiles(ctx, dirtyFiles, reader)
 		} else {
 			fmt.Println("No files to revert.")
 		}
 	case "5":
+		var addPaths []string
+		for _, file := range dirtyFiles {
+			if file.Action == "add" {
+				addPaths = append(addPaths, file.Path)
+			}
+		}
+		editP4Ignore(ctx, addPaths, reader)
+	case "6":
 		return
 	default:
 		fmt.Println("Invalid choice.")
@@ -241,254 +314,162 @@ func scanAndShowFiles(selectedFolders []string, reader *bufio.Reader) {
 	reader.ReadString('\n')
 }
 
-func isP4Available() bool {
-	cmd := exec.Command("p4", "info")
-	err := cmd.Run()
+func isP4Available(ctx context.Context) bool {
+	_, err := runP4(ctx, "info")
 	return err == nil
 }
 
-func getP4Info() (*P4Info, error) {
-	cmd := exec.Command("p4", "info")
-	output, err := cmd.Output()
+func getP4Info(ctx context.Context) (*P4Info, error) {
+	record, err := p4InfoRecord(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	info := &P4Info{}
+	info := &P4Info{
+		UserName:     record["userName"],
+		ClientName:   record["clientName"],
+		ClientHost:   record["clientHost"],
+		ClientRoot:   record["clientRoot"],
+		ServerAddr:   record["serverAddress"],
+		ServerUptime: record["serverUptime"],
+		CaseHandling: record["caseHandling"],
+	}
 	cwd, _ := os.Getwd()
 	info.CurrentDir = cwd
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "User name:") {
-			info.UserName = extractValue(line)
-		} else if strings.HasPrefix(line, "Client name:") {
-			info.ClientName = extractValue(line)
-		} else if strings.HasPrefix(line, "Client host:") {
-			info.ClientHost = extractValue(line)
-		} else if strings.HasPrefix(line, "Client root:") {
-			info.ClientRoot = extractValue(line)
-		} else if strings.HasPrefix(line, "Server address:") {
-			info.ServerAddr = extractValue(line)
-		} else if strings.HasPrefix(line, "Server uptime:") {
-			info.ServerUptime = extractValue(line)
-		}
-	}
-
-	if info.ClientName == "" {
+	if info.ClientName == "" || info.ClientName == "*unknown*" {
 		return nil, fmt.Errorf("could not determine client name")
 	}
 
 	return info, nil
 }
 
-func extractValue(line string) string {
-	parts := strings.SplitN(line, ":", 2)
-	if len(parts) >= 2 {
-		return strings.TrimSpace(parts[1])
-	}
-	return ""
-}
-
-func getPendingFiles() ([]string, error) {
-	cmd := exec.Command("p4", "opened")
-	output, err := cmd.Output()
+func getPendingFiles(ctx context.Context) ([]string, error) {
+	records, err := p4Opened(ctx)
 
 	// If no files are opened, p4 returns an error
 	if err != nil {
-		if len(output) == 0 {
+		if isNoMatch(err) {
 			return []string{}, nil
 		}
 		return nil, err
 	}
 
 	var files []string
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		// Parse lines like: "//depot/path/file.txt#1 - edit default change (text)"
-		parts := strings.Split(line, "#")
-		if len(parts) >= 1 {
-			files = append(files, strings.TrimSpace(parts[0]))
-		}
+	for _, record := range records {
+		files = append(files, record.DepotFile)
 	}
 
 	return files, nil
 }
 
-func findDirtyFiles() ([]DirtyFile, error) {
-	p4Info, _ := getP4Info()
+func findDirtyFiles(ctx context.Context) ([]DirtyFile, error) {
+	p4Info, _ := getP4Info(ctx)
 	contentPath := filepath.Join(p4Info.ClientRoot, "Project", "Content")
-	return findDirtyFilesInFolders([]string{contentPath}, false)
+	return findDirtyFilesInFolders(ctx, []string{contentPath}, false)
 }
 
-func findDirtyFilesInFolders(folders []string, verbose bool) ([]DirtyFile, error) {
-	var allDirtyFiles []DirtyFile
+func findDirtyFilesInFolders(ctx context.Context, folders []string, verbose bool) ([]DirtyFile, error) {
+	// Folders are reconciled in parallel; on interruption the files found so
+	// far are still returned along with the error
+	records, err := reconcileFolders(ctx, folders, verbose)
 
-	for _, folder := range folders {
-		files, err := scanFolder(folder, verbose)
-		if err != nil {
-			return nil, err
+	// Adds matching P4IGNORE files or the ignore preset are left out
+	matcher := newIgnoreMatcher(ctx)
+	ignored := &IgnoreStats{}
+
+	var allDirtyFiles []DirtyFile
+	for _, record := range records {
+		path := recordLocalPath(record)
+		if record.Action == "add" {
+			if rule := matcher.Match(path); rule != nil {
+				ignored.add(rule)
+				continue
+			}
 		}
-		allDirtyFiles = append(allDirtyFiles, files...)
+		allDirtyFiles = append(allDirtyFiles, DirtyFile{
+			Path:        path,
+			Action:      record.Action,
+			FileDetails: detailsFromRecord(record),
+		})
 	}
 
-	return allDirtyFiles, nil
-}
-
-func scanFolder(folder string, verbose bool) ([]DirtyFile, error) {
-	// Run p4 reconcile in preview mode
-	if verbose {
-		fmt.Printf("  Executing: p4 reconcile -n %s/...\n", folder)
+	// Renamed assets show up as a delete plus an add; report them as moves
+	if err == nil {
+		allDirtyFiles = pairMovedFiles(ctx, allDirtyFiles)
 	}
 
-	cmd := exec.Command("p4", "reconcile", "-n", filepath.Join(folder, "..."))
-	cmd.Dir = folder
-
-	// Create a channel to track progress
-	done := make(chan bool)
-	if verbose {
-		go func() {
-			spinner := []string{"|", "/", "-", "\\"}
-			i := 0
-			startTime := time.Now()
-			for {
-				select {
-				case <-done:
-					fmt.Printf("\r  ✓ Complete (took %.1f seconds)\n", time.Since(startTime).Seconds())
-					return
-				default:
-					elapsed := time.Since(startTime).Seconds()
-					fmt.Printf("\r  %s Scanning... (%.0fs) [Ctrl+C to cancel]", spinner[i%len(spinner)], elapsed)
-					i++
-					time.Sleep(100 * time.Millisecond)
-				}
-			}
-		}()
+	details := make([]*FileDetails, len(allDirtyFiles))
+	for i := range allDirtyFiles {
+		details[i] = &allDirtyFiles[i].FileDetails
 	}
-
-	output, _ := cmd.CombinedOutput()
+	fillFileDetails(ctx, details)
 
 	if verbose {
-		done <- true
+		ignored.Print("  ")
 	}
 
-	// Note: p4 reconcile -n can return various exit codes
-	outputStr := string(output)
+	return allDirtyFiles, err
+}
 
-	if verbose {
-		fmt.Printf("  Processing results...\n")
+func depotToLocalPath(ctx context.Context, depotPath string) (string, error) {
+	if localPath, ok := cachedLocalPath(depotPath); ok {
+		return localPath, nil
 	}
-
-	var dirtyFiles []DirtyFile
-	fileCount := 0
-
-	lines := strings.Split(outputStr, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Parse different p4 reconcile -n output formats:
-		// "//depot/path/file.txt#1 - opened for edit"
-		// "//depot/path/file.txt#1 - reconcile to edit"
-		// "//depot/path/file.txt - reconcile to add"
-		if strings.Contains(line, "//") && (strings.Contains(line, " - opened for ") ||
-		   strings.Contains(line, " - reconcile to ") || strings.Contains(line, "- currently opened for")) {
-
-			// Extract depot path
-			depotPath := ""
-			if strings.Contains(line, "#") {
-				parts := strings.Split(line, "#")
-				depotPath = strings.TrimSpace(parts[0])
-			} else {
-				parts := strings.Split(line, " - ")
-				if len(parts) >= 1 {
-					depotPath = strings.TrimSpace(parts[0])
-				}
-			}
-
-			if depotPath == "" {
-				continue
-			}
-
-			// Convert depot path to local path
-			localPath, err := depotToLocalPath(depotPath)
-			if err != nil {
-				// If conversion fails, use depot path
-				localPath = depotPath
-			}
-
-			// Determine action
-			action := "edit"
-			if strings.Contains(line, "add") {
-				action = "add"
-			} else if strings.Contains(line, "delete") {
-				action = "delete"
-			}
-
-			dirtyFiles = append(dirtyFiles, DirtyFile{
-				Path:   localPath,
-				Action: action,
-			})
-
-			fileCount++
-			if verbose && fileCount%10 == 0 {
-				fmt.Printf("  ... found %d files so far\n", fileCount)
-			}
+	if view, err := getClientView(ctx); err == nil {
+		if localPath, ok := view.DepotToLocal(depotPath); ok {
+			cacheLocalPath(depotPath, localPath)
+			return localPath, nil
 		}
 	}
 
-	return dirtyFiles, nil
-}
-
-func depotToLocalPath(depotPath string) (string, error) {
-	cmd := exec.Command("p4", "where", depotPath)
-	output, err := cmd.Output()
+	records, err := p4Where(ctx, depotPath)
 	if err != nil {
 		return "", err
 	}
 
-	// p4 where output: "depot-path client-path local-path"
-	parts := strings.Fields(string(output))
-	if len(parts) >= 3 {
-		return filepath.Clean(parts[2]), nil
+	for _, record := range records {
+		if record.LocalPath != "" {
+			cacheLocalPath(depotPath, record.LocalPath)
+			return filepath.Clean(record.LocalPath), nil
+		}
 	}
 
 	return "", fmt.Errorf("could not convert depot path to local path")
 }
 
-func checkoutFiles(files []DirtyFile) {
+func checkoutFiles(ctx context.Context, files []DirtyFile) {
 	fmt.Println("\nChecking out files...")
 
+	var paths []string
+	var moves []MovedPath
 	for _, file := range files {
-		fmt.Printf("  p4 edit %s\n", file.Path)
-		cmd := exec.Command("p4", "edit", file.Path)
-		output, err := cmd.CombinedOutput()
-
-		if err != nil {
-			fmt.Printf("    Error: %v\n", err)
-			if len(output) > 0 {
-				fmt.Printf("    %s\n", string(output))
-			}
-		} else {
-			fmt.Printf("    %s\n", strings.TrimSpace(string(output)))
+		if file.MovedFrom != "" {
+			moves = append(moves, MovedPath{From: file.MovedFrom, To: file.Path})
+			continue
 		}
+		paths = append(paths, file.Path)
+	}
+
+	if len(paths) > 0 {
+		result := runP4Batch(ctx, paths, "edit")
+		printBatchSummary(result)
+	}
+
+	// Renames are opened with p4 move so the new path keeps the file's history
+	if len(moves) > 0 {
+		result := openMoves(ctx, moves)
+		printBatchSummary(result)
 	}
 
 	fmt.Println("\nDone!")
 }
 
-func reconcileFiles() {
+func reconcileFiles(ctx context.Context) {
 	fmt.Println("\nReconciling files...")
 	fmt.Println("This will open files for add, edit, or delete to match your workspace (Project/Content folder only).")
 
-	p4Info, err := getP4Info()
+	p4Info, err := getP4Info(ctx)
 	if err != nil {
 		fmt.Printf("Error getting workspace info: %v\n", err)
 		return
@@ -497,9 +478,7 @@ func reconcileFiles() {
 	// Only reconcile the Content folder
 	contentPath := filepath.Join(p4Info.ClientRoot, "Project", "Content")
 
-	cmd := exec.Command("p4", "reconcile", filepath.Join(contentPath, "..."))
-	cmd.Dir = contentPath
-	output, err := cmd.CombinedOutput()
+	output, err := runP4CombinedIn(ctx, contentPath, "reconcile", filepath.Join(contentPath, "..."))
 
 	outputStr := string(output)
 
@@ -520,11 +499,11 @@ func reconcileFiles() {
 	fmt.Println("\nDone!")
 }
 
-func showReconcilePreview() {
+func showReconcilePreview(ctx context.Context) {
 	fmt.Println("\nReconcile Preview (what would happen in Project/Content folder):")
 	fmt.Println("─────────────────────────────────────")
 
-	p4Info, err := getP4Info()
+	p4Info, err := getP4Info(ctx)
 	if err != nil {
 		fmt.Printf("Error getting workspace info: %v\n", err)
 		return
@@ -533,9 +512,7 @@ func showReconcilePreview() {
 	// Only reconcile the Content folder
 	contentPath := filepath.Join(p4Info.ClientRoot, "Project", "Content")
 
-	cmd := exec.Command("p4", "reconcile", "-n", filepath.Join(contentPath, "..."))
-	cmd.Dir = contentPath
-	output, err := cmd.CombinedOutput()
+	output, err := runP4CombinedIn(ctx, contentPath, "reconcile", "-n", filepath.Join(contentPath, "..."))
 
 	outputStr := string(output)
 
@@ -553,12 +530,12 @@ func showAllFiles(files []DirtyFile) {
 	fmt.Println("\nAll Modified Files:")
 	fmt.Println("─────────────────────────────────────")
 	for i, file := range files {
-		fmt.Printf("  %d. [%s] %s\n", i+1, file.Action, file.Path)
+		fmt.Printf("  %d. [%s] %s\n", i+1, file.Action, movePathLabel(file.Path, file.MovedFrom))
 	}
 	fmt.Println("─────────────────────────────────────")
 }
 
-func selectAndCheckoutFiles(files []DirtyFile) {
+func selectAndCheckoutFiles(ctx context.Context, files []DirtyFile) {
 	fmt.Println("\nSelect files to checkout (comma-separated numbers, or 'all'):")
 	showAllFiles(files)
 
@@ -593,18 +570,25 @@ func selectAndCheckoutFiles(files []DirtyFile) {
 		return
 	}
 
+	// UE assets are usually +l; find out who holds them before p4 edit fails
+	selectedFiles = filterCheckoutConflicts(ctx, selectedFiles, reader)
+	if len(selectedFiles) == 0 {
+		return
+	}
+
 	fmt.Printf("\nChecking out %d file(s)...\n", len(selectedFiles))
-	checkoutFiles(selectedFiles)
+	checkoutFiles(ctx, selectedFiles)
 }
 
-func filterByAction(files []DirtyFile, reader *bufio.Reader) {
+func filterByAction(ctx context.Context, files []DirtyFile, reader *bufio.Reader) {
 	fmt.Println("\n─────────────────────────────────────")
 	fmt.Println("Filter by action type:")
 	fmt.Println("  1. Show only Edits")
 	fmt.Println("  2. Show only Adds")
 	fmt.Println("  3. Show only Deletes")
-	fmt.Println("  4. Show All")
-	fmt.Print("\nEnter choice (1-4): ")
+	fmt.Println("  4. Show only Moves")
+	fmt.Println("  5. Show All")
+	fmt.Print("\nEnter choice (1-5): ")
 
 	input, _ := reader.ReadString('\n')
 	input = strings.TrimSpace(input)
@@ -635,6 +619,13 @@ func filterByAction(files []DirtyFile, reader *bufio.Reader) {
 			}
 		}
 	case "4":
+		filterType = "Moves"
+		for _, file := range files {
+			if file.Action == "move" {
+				filtered = append(filtered, file)
+			}
+		}
+	case "5":
 		filtered = files
 	default:
 		fmt.Println("Invalid choice.")
@@ -655,15 +646,15 @@ func filterByAction(files []DirtyFile, reader *bufio.Reader) {
 
 	switch choice {
 	case "1":
-		selectAndCheckoutFiles(filtered)
+		selectAndCheckoutFiles(ctx, filtered)
 	case "2":
-		revertFiles(filtered, reader)
+		revertFiles(ctx, filtered, reader)
 	case "3":
 		return
 	}
 }
 
-func revertFiles(files []DirtyFile, reader *bufio.Reader) {
+func revertFiles(ctx context.Context, files []DirtyFile, reader *bufio.Reader) {
 	fmt.Println("\n⚠️  WARNING: REVERT FILES ⚠️")
 	fmt.Println("═══════════════════════════════════════════════════════════════════════════════")
 	fmt.Println("This will PERMANENTLY DELETE your local changes and restore files from P4!")
@@ -717,33 +708,33 @@ func revertFiles(files []DirtyFile, reader *bufio.Reader) {
 	}
 
 	fmt.Println("\nReverting files...")
+	var paths []string
+	var moves []MovedPath
 	for _, file := range selectedFiles {
-		fmt.Printf("  p4 sync -f %s\n", file.Path)
-		cmd := exec.Command("p4", "sync", "-f", file.Path)
-		output, err := cmd.CombinedOutput()
-
-		if err != nil {
-			fmt.Printf("    Error: %v\n", err)
-			if len(output) > 0 {
-				fmt.Printf("    %s\n", string(output))
-			}
-		} else {
-			fmt.Printf("    ✓ %s\n", strings.TrimSpace(string(output)))
+		if file.MovedFrom != "" {
+			moves = append(moves, MovedPath{From: file.MovedFrom, To: file.Path})
+			continue
 		}
+		paths = append(paths, file.Path)
 	}
 
+	result := restoreFromDepot(ctx, paths, moves)
+	printBatchSummary(result)
+
+	if len(result.Failed()) > 0 {
+		fmt.Println("\n⚠ Done, but some files could not be reverted (see above).")
+		return
+	}
 	fmt.Println("\n✓ Done! Files have been reverted to P4 versions.")
 }
 
-func reconcileFilesInFolders(folders []string) {
+func reconcileFilesInFolders(ctx context.Context, folders []string) {
 	fmt.Println("\nReconciling files in selected folders...")
 	fmt.Println("This will open files for add, edit, or delete to match your workspace.")
 
 	for _, folder := range folders {
 		fmt.Printf("\nReconciling: %s\n", folder)
-		cmd := exec.Command("p4", "reconcile", filepath.Join(folder, "..."))
-		cmd.Dir = folder
-		output, err := cmd.CombinedOutput()
+		output, err := runP4CombinedIn(ctx, folder, "reconcile", filepath.Join(folder, "..."))
 
 		outputStr := string(output)
 
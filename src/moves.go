@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// moveCandidate is one half of a possible rename: a deleted or added file
+type moveCandidate struct {
+	Path      string // local path
+	Action    string // "delete", "add", "move/delete" or "move/add"
+	DepotPath string
+	MovedFile string // depot path of the other half, when p4 paired them
+}
+
+// MovedPath is a rename to record on the server
+type MovedPath struct {
+	From string
+	To   string
+}
+
+// ============================================================================
+// PAIRING
+// ============================================================================
+
+// findMovePairs pairs deleted files with the added files they were renamed
+// to and returns, for each paired add, the index of its delete. Renames the
+// server already detected (reconcile opens them as move/delete and move/add)
+// are taken as they are; the remaining deletes are matched with adds of the
+// same extension whose content equals the deleted have revision, comparing
+// size and MD5 with "p4 fstat -Ol" locally.
+func findMovePairs(ctx context.Context, candidates []moveCandidate) map[int]int {
+	pairs := make(map[int]int)
+
+	var deletes, adds []int
+	for i, candidate := range candidates {
+		switch candidate.Action {
+		case "delete", "move/delete":
+			deletes = append(deletes, i)
+		case "add", "move/add":
+			adds = append(adds, i)
+		}
+	}
+	if len(deletes) == 0 || len(adds) == 0 {
+		return pairs
+	}
+
+	// Pairs reported by the server, from either side
+	addByDepot := make(map[string]int)
+	addByMovedFile := make(map[string]int)
+	for _, a := range adds {
+		if candidates[a].DepotPath != "" {
+			addByDepot[candidates[a].DepotPath] = a
+		}
+		if candidates[a].MovedFile != "" {
+			addByMovedFile[candidates[a].MovedFile] = a
+		}
+	}
+
+	paired := make(map[int]bool)
+	var unmatched []int
+	for _, d := range deletes {
+		del := candidates[d]
+		a, ok := addByDepot[del.MovedFile]
+		if !ok || del.MovedFile == "" {
+			a, ok = addByMovedFile[del.DepotPath]
+		}
+		if ok && del.DepotPath != "" && !paired[a] {
+			pairs[a] = d
+			paired[a] = true
+			continue
+		}
+		unmatched = append(unmatched, d)
+	}
+	if len(unmatched) == 0 || len(paired) == len(adds) {
+		return pairs
+	}
+
+	// Everything else is matched by content
+	var depotPaths []string
+	for _, d := range unmatched {
+		if candidates[d].DepotPath != "" {
+			depotPaths = append(depotPaths, candidates[d].DepotPath)
+		}
+	}
+	haveRevisions := fetchHaveDigests(ctx, depotPaths)
+	hasher := newMoveHasher()
+
+	for _, d := range unmatched {
+		if ctx.Err() != nil {
+			break
+		}
+
+		have, ok := haveRevisions[candidates[d].DepotPath]
+		if !ok || have.Digest == "" {
+			continue
+		}
+		isText, ok := digestFileKind(have.Type)
+		if !ok {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(candidates[d].Path))
+		for _, a := range adds {
+			add := candidates[a]
+			if paired[a] || strings.ToLower(filepath.Ext(add.Path)) != ext {
+				continue
+			}
+
+			// Binary files are stored byte for byte, so the size must match
+			size, exists := hasher.size(add.Path)
+			if !exists || (!isText && have.FileSize > 0 && size != have.FileSize) {
+				continue
+			}
+
+			if digest := hasher.digest(add.Path, isText); digest != "" && strings.EqualFold(digest, have.Digest) {
+				pairs[a] = d
+				paired[a] = true
+				break
+			}
+		}
+	}
+
+	return pairs
+}
+
+// fetchHaveDigests returns the type, size and digest of the have revision of
+// each depot path with batched "p4 fstat -Ol" calls
+func fetchHaveDigests(ctx context.Context, depotPaths []string) map[string]FileRecord {
+	revisions := make(map[string]FileRecord)
+
+	for _, chunk := range chunkFiles(depotPaths, batchMaxFiles, batchMaxBytes) {
+		if ctx.Err() != nil {
+			break
+		}
+
+		lines := make([]string, len(chunk))
+		for i, depotPath := range chunk {
+			lines[i] = depotPath + "#have"
+		}
+
+		// Files without a have revision make fstat exit non-zero; the
+		// records for the others are still valid
+		records, _ := runP4TaggedArgFile(ctx, lines, "fstat", "-Ol", "-T", "depotFile,headType,fileSize,digest")
+		for _, r := range records {
+			record := fileRecordFrom(r)
+			if record.DepotFile != "" {
+				revisions[record.DepotFile] = record
+			}
+		}
+	}
+
+	return revisions
+}
+
+// moveHasher remembers local sizes and digests of added files, since each
+// one may be compared with several deletes
+type moveHasher struct {
+	sizes   map[string]int64
+	digests map[string]string
+}
+
+// newMoveHasher creates an empty moveHasher
+func newMoveHasher() *moveHasher {
+	return &moveHasher{sizes: make(map[string]int64), digests: make(map[string]string)}
+}
+
+// size returns the local size of a file, and false when it is not a regular file
+func (h *moveHasher) size(path string) (int64, bool) {
+	if size, ok := h.sizes[path]; ok {
+		return size, size >= 0
+	}
+
+	size := int64(-1)
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		size = info.Size()
+	}
+	h.sizes[path] = size
+	return size, size >= 0
+}
+
+// digest returns the MD5 of a local file hashed as text or binary, or "" when
+// it can't be read
+func (h *moveHasher) digest(path string, isText bool) string {
+	key := fmt.Sprintf("%t|%s", isText, path)
+	if digest, ok := h.digests[key]; ok {
+		return digest
+	}
+
+	digest, _ := localFileDigest(path, isText)
+	h.digests[key] = digest
+	return digest
+}
+
+// movable is a scanned file type whose deletes and adds can be paired into moves
+type movable[T any] interface {
+	moveCandidate() moveCandidate
+	movedFrom(from T) T
+}
+
+// moveCandidate describes a scanned file for findMovePairs
+func (f ModifiedFile) moveCandidate() moveCandidate {
+	return moveCandidate{Path: f.Path, Action: f.Action, DepotPath: f.DepotPath, MovedFile: f.MovedFile}
+}
+
+// movedFrom turns an added file into the "move" entry of a rename from from
+func (f ModifiedFile) movedFrom(from ModifiedFile) ModifiedFile {
+	f.Action = "move"
+	f.MovedFrom = from.Path
+	f.FileDetails = movedDetails(from.FileDetails, f.Path)
+	return f
+}
+
+// moveCandidate describes a scanned file for findMovePairs
+func (f DirtyFile) moveCandidate() moveCandidate {
+	return moveCandidate{Path: f.Path, Action: f.Action, DepotPath: f.DepotPath, MovedFile: f.MovedFile}
+}
+
+// movedFrom turns an added file into the "move" entry of a rename from from
+func (f DirtyFile) movedFrom(from DirtyFile) DirtyFile {
+	f.Action = "move"
+	f.MovedFrom = from.Path
+	f.FileDetails = movedDetails(from.FileDetails, f.Path)
+	return f
+}
+
+// pairMovedFiles replaces every delete/add pair that is a rename with a single
+// "move" entry for the new path
+func pairMovedFiles[T movable[T]](ctx context.Context, files []T) []T {
+	candidates := make([]moveCandidate, len(files))
+	for i, file := range files {
+		candidates[i] = file.moveCandidate()
+	}
+
+	pairs := findMovePairs(ctx, candidates)
+	if len(pairs) == 0 {
+		return files
+	}
+
+	moved := make(map[int]bool)
+	for _, d := range pairs {
+		moved[d] = true
+	}
+
+	paired := make([]T, 0, len(files)-len(pairs))
+	for i, file := range files {
+		if moved[i] {
+			continue
+		}
+		if d, ok := pairs[i]; ok {
+			file = file.movedFrom(files[d])
+		}
+		paired = append(paired, file)
+	}
+
+	return paired
+}
+
+// movedDetails describes a move by the depot file it comes from and the
+// local file it now lives in
+func movedDetails(from FileDetails, localPath string) FileDetails {
+	details := from
+	details.LocalPath = localPath
+	details.MovedFile = ""
+	return details
+}
+
+// countMoves returns how many files are "move" entries
+func countMoves(files []ModifiedFile) int {
+	count := 0
+	for _, file := range files {
+		if file.MovedFrom != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// movePathLabel shows a move as "old → new", other files by their path
+func movePathLabel(path string, movedFrom string) string {
+	if movedFrom == "" {
+		return path
+	}
+	return movedFrom + " → " + path
+}
+
+// ============================================================================
+// OPENING MOVES
+// ============================================================================
+
+// openMoves records renames on the server so history follows the files: the
+// old path is opened with "p4 edit", then "p4 move -k" moves it to the new
+// path without touching the files that were already moved locally
+func openMoves(ctx context.Context, moves []MovedPath) *BatchResult {
+	result := &BatchResult{Command: "p4 move -k"}
+
+	froms := make([]string, len(moves))
+	for i, move := range moves {
+		froms[i] = move.From
+	}
+	edits := runP4Batch(ctx, froms, "edit")
+
+	for i, move := range moves {
+		if !edits.Files[i].OK {
+			result.Files = append(result.Files, BatchFileResult{File: move.To, Message: "p4 edit of " + move.From + " failed: " + edits.Files[i].Message})
+			continue
+		}
+		if ctx.Err() != nil {
+			result.Files = append(result.Files, BatchFileResult{File: move.To, Message: errCancelled.Error()})
+			continue
+		}
+
+		fmt.Printf("  [%d/%d] p4 move -k %s %s\n", i+1, len(moves), move.From, move.To)
+		output, err := runP4Combined(ctx, "move", "-k", move.From, move.To)
+		message := strings.TrimSpace(string(output))
+		if err != nil && message == "" {
+			message = err.Error()
+		}
+		result.Files = append(result.Files, BatchFileResult{File: move.To, OK: err == nil, Message: batchMessageText(message)})
+	}
+
+	return result
+}
+
+// ============================================================================
+// UNDOING MOVES
+// ============================================================================
+
+// restoreFromDepot force syncs paths back to the have revision. Local renames
+// are undone on both sides: the old path is synced back, then the new copy,
+// which p4 does not know about, is deleted.
+func restoreFromDepot(ctx context.Context, paths []string, moves []MovedPath) *BatchResult {
+	files := append([]string{}, paths...)
+	for _, move := range moves {
+		files = append(files, move.From)
+	}
+	result := runP4Batch(ctx, files, "sync", "-f")
+
+	for i, move := range moves {
+		// Keep the new copy when the old path could not be restored, so the
+		// content is not lost
+		if !result.Files[len(paths)+i].OK {
+			continue
+		}
+		if err := removeMovedCopy(move); err != nil {
+			result.Files = append(result.Files, BatchFileResult{File: move.To, Message: "could not remove the renamed copy: " + err.Error()})
+		}
+	}
+
+	return result
+}
+
+// removeMovedCopy deletes the new path of an undone rename, unless it is the
+// restored file itself (a case-only rename on a case-insensitive disk)
+func removeMovedCopy(move MovedPath) error {
+	restored, err := os.Stat(move.From)
+	if err != nil {
+		return err
+	}
+	copied, err := os.Stat(move.To)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if os.SameFile(restored, copied) {
+		return nil
+	}
+	return os.Remove(move.To)
+}
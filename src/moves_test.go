@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreFromDepotUndoesMoves(t *testing.T) {
+	root, fake := setupFakeWorkspace(t)
+	ini := writeTestFile(t, root, "Config/Game.ini", "local")
+	renamedFrom := writeTestFile(t, root, "Content/Old.uasset", "restored by the sync")
+	renamedTo := writeTestFile(t, root, "Content/New.uasset", "renamed")
+	lostFrom := filepath.Join(root, "Content", "Lost.uasset")
+	keptTo := writeTestFile(t, root, "Content/Kept.uasset", "renamed")
+	fake.On("-s -x - sync -f", FakeResponse{Stdout: "info: //depot/Game/Config/Game.ini#4 - refreshing " + ini + "\n" +
+		"info: //depot/Game/Content/Old.uasset#2 - refreshing " + renamedFrom + "\n" +
+		"error: " + lostFrom + " - file(s) not on client.\n"})
+
+	result := restoreFromDepot(context.Background(), []string{ini}, []MovedPath{
+		{From: renamedFrom, To: renamedTo},
+		{From: lostFrom, To: keptTo},
+	})
+
+	if want := ini + "\n" + renamedFrom + "\n" + lostFrom + "\n"; len(fake.Calls) != 1 || fake.Calls[0].Stdin != want {
+		t.Fatalf("calls = %+v, want one sync of %q", fake.Calls, want)
+	}
+	if failed := result.Failed(); len(failed) != 1 || failed[0].File != lostFrom {
+		t.Errorf("failed = %+v, want only %s", failed, lostFrom)
+	}
+	if _, err := os.Stat(renamedTo); !os.IsNotExist(err) {
+		t.Errorf("renamed copy still exists (err = %v), want it removed once the old path is restored", err)
+	}
+	if _, err := os.Stat(keptTo); err != nil {
+		t.Errorf("renamed copy of an unrestored file was removed: %v", err)
+	}
+}
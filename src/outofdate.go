@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RevisionInfo is one submitted revision of a file
+type RevisionInfo struct {
+	Rev    int
+	Change string
+	User   string
+	Client string
+	Time   time.Time
+	Desc   string
+}
+
+// ============================================================================
+// DETECTION
+// ============================================================================
+
+// getOpenedFilesOutOfDate returns opened files whose have revision is older
+// than head, with the revisions submitted since the have revision
+func getOpenedFilesOutOfDate(ctx context.Context, scopePath string, verbose bool) ([]ModifiedFile, error) {
+	spec := scopePath
+	if spec == "" {
+		spec = "//..."
+	}
+	if verbose {
+		fmt.Printf("  Executing: p4 -ztag fstat -Ro %s\n", spec)
+	}
+
+	// fstat exits non-zero when nothing is opened in scope
+	records, err := p4Fstat(ctx, "-Ro", "-T", "depotFile,clientFile,action,change,headType,haveRev,headRev", spec)
+	if err != nil && !isNoMatch(err) {
+		return []ModifiedFile{}, err
+	}
+
+	var stale []FileRecord
+	for _, record := range records {
+		// Adds and branches have no have revision to be behind
+		if record.HaveRev > 0 && record.HaveRev < record.HeadRev {
+			stale = append(stale, record)
+		}
+	}
+	resolveLocalPaths(ctx, stale)
+
+	newer, err := getNewerRevisions(ctx, stale)
+
+	files := []ModifiedFile{}
+	for _, record := range stale {
+		files = append(files, ModifiedFile{
+			Path:           recordLocalPath(record),
+			Action:         record.Action,
+			HasChanges:     true,
+			IsOpened:       true,
+			NewerRevisions: newer[record.DepotFile],
+			FileDetails:    detailsFromRecord(record),
+		})
+	}
+
+	return files, err
+}
+
+// getNewerRevisions returns, per depot file, the revisions between the have
+// revision and head (newest first) from one batched "p4 filelog"
+func getNewerRevisions(ctx context.Context, records []FileRecord) (map[string][]RevisionInfo, error) {
+	newer := make(map[string][]RevisionInfo)
+
+	var lines []string
+	for _, record := range records {
+		lines = append(lines, fmt.Sprintf("%s#%d,#%d", record.DepotFile, record.HaveRev+1, record.HeadRev))
+	}
+
+	for _, chunk := range chunkFiles(lines, batchMaxFiles, batchMaxBytes) {
+		if ctx.Err() != nil {
+			return newer, errCancelled
+		}
+
+		filelogs, err := runP4TaggedArgFile(ctx, chunk, "filelog", "-s")
+		if isInterrupted(ctx, err) {
+			return newer, err
+		}
+		for _, r := range filelogs {
+			if r["depotFile"] != "" {
+				newer[r["depotFile"]] = revisionsFromFilelog(r)
+			}
+		}
+	}
+
+	return newer, nil
+}
+
+// revisionsFromFilelog decodes the numbered rev0, change0, user0... fields of
+// a tagged filelog record
+func revisionsFromFilelog(r P4Record) []RevisionInfo {
+	var revisions []RevisionInfo
+	for i := 0; ; i++ {
+		suffix := strconv.Itoa(i)
+		if _, ok := r["rev"+suffix]; !ok {
+			break
+		}
+
+		revision := RevisionInfo{
+			Rev:    r.Int("rev" + suffix),
+			Change: r["change"+suffix],
+			User:   r["user"+suffix],
+			Client: r["client"+suffix],
+			Desc:   strings.TrimSpace(r["desc"+suffix]),
+		}
+		if seconds, err := strconv.ParseInt(r["time"+suffix], 10, 64); err == nil {
+			revision.Time = time.Unix(seconds, 0)
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions
+}
+
+// submittersLabel lists who submitted the newer revisions, e.g. "alice (2), bob"
+func submittersLabel(revisions []RevisionInfo) string {
+	if len(revisions) == 0 {
+		return "-"
+	}
+
+	var users []string
+	counts := make(map[string]int)
+	for _, revision := range revisions {
+		if counts[revision.User] == 0 {
+			users = append(users, revision.User)
+		}
+		counts[revision.User]++
+	}
+
+	labels := make([]string, len(users))
+	for i, user := range users {
+		labels[i] = user
+		if counts[user] > 1 {
+			labels[i] = fmt.Sprintf("%s (%d)", user, counts[user])
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+// ============================================================================
+// DISPLAY AND ACTIONS
+// ============================================================================
+
+// printOutOfDateTable prints out-of-date files with who submitted since, up
+// to limit rows
+func printOutOfDateTable(files []ModifiedFile, limit int) {
+	fmt.Printf("  %-8s %-11s %-8s %-28s  %s\n", "ACTION", "REV", "CL", "SUBMITTED SINCE BY", "PATH")
+	fmt.Printf("  %-8s %-11s %-8s %-28s  %s\n", "──────", "───", "──", "──────────────────", "────")
+
+	for i, file := range files {
+		if i >= limit {
+			fmt.Printf("  ... and %d more\n", len(files)-limit)
+			break
+		}
+		fmt.Printf("  %-8s %-11s %-8s %-28s  %s\n",
+			strings.ToUpper(file.Action),
+			file.RevLabel(),
+			truncate(file.ChangeLabel(), 8),
+			truncate(submittersLabel(file.NewerRevisions), 28),
+			file.Path)
+
+		// The latest submit is usually the one to talk to
+		if len(file.NewerRevisions) > 0 {
+			latest := file.NewerRevisions[0]
+			fmt.Printf("           └ #%d in %s by %s@%s: %s\n", latest.Rev, latest.Change, latest.User, latest.Client, truncate(firstLine(latest.Desc), 50))
+		}
+	}
+}
+
+// firstLine returns the first line of a changelist description
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(line)
+}
+
+// syncOutOfDateFiles offers to sync out-of-date opened files to head, which
+// makes p4 schedule a resolve for each of them. It reports whether any file
+// was synced.
+func syncOutOfDateFiles(ctx context.Context, files []ModifiedFile, reader *bufio.Reader) bool {
+	if len(files) == 0 {
+		return false
+	}
+
+	fmt.Println("\n─────────────────────────────────────")
+	fmt.Println("SYNC OUT-OF-DATE FILES")
+	fmt.Println("─────────────────────────────────────")
+	fmt.Printf("%d opened file(s) are behind head and will fail to submit.\n", len(files))
+	fmt.Println("Syncing them keeps your edits and schedules a resolve for each file.")
+	fmt.Print("\nSync to head now? (y/n): ")
+
+	confirm, _ := reader.ReadString('\n')
+	confirm = strings.TrimSpace(strings.ToLower(confirm))
+	if confirm != "y" && confirm != "yes" {
+		fmt.Println("Skipped.")
+		return false
+	}
+
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+
+	fmt.Println("\nSyncing out-of-date files...")
+	result := runP4Batch(ctx, paths, "sync")
+	printBatchSummary(result)
+
+	if len(result.Failed()) == len(result.Files) {
+		return false
+	}
+	fmt.Println("\n✓ Synced. These files must be resolved before submitting.")
+	return true
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FakeResponse is a scripted reply returned by FakeP4Runner
+type FakeResponse struct {
+	Stdout string
+	Stderr string
+	Err    error
+}
+
+// FakeP4Runner is an in-memory P4Runner that replays scripted responses.
+// It needs no Perforce server, so scanner and hijack logic can be exercised
+// anywhere:
+//
+//	fake := NewFakeP4Runner()
+//	fake.On("diff -se", FakeResponse{Stdout: "/ws/a.txt\n"})
+//	defer setP4Runner(setP4Runner(fake))
+type FakeP4Runner struct {
+	mu        sync.Mutex
+	responses map[string][]FakeResponse
+
+	// Fallback answers commands that have no scripted response. When nil,
+	// unscripted commands fail with an error.
+	Fallback func(cmd P4Command) FakeResponse
+
+	// Calls records every command in the order it was run
+	Calls []P4Command
+}
+
+// NewFakeP4Runner creates an empty fake runner
+func NewFakeP4Runner() *FakeP4Runner {
+	return &FakeP4Runner{responses: make(map[string][]FakeResponse)}
+}
+
+// On scripts a response for the given space-separated arguments. Scripting the
+// same arguments several times queues the responses; the last one repeats.
+func (f *FakeP4Runner) On(args string, response FakeResponse) *FakeP4Runner {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := fakeKey(strings.Fields(args))
+	f.responses[key] = append(f.responses[key], response)
+	return f
+}
+
+// Run records the command and returns its scripted response
+func (f *FakeP4Runner) Run(ctx context.Context, cmd P4Command) (*P4Output, error) {
+	if ctx.Err() != nil {
+		return &P4Output{}, errCancelled
+	}
+
+	f.mu.Lock()
+	f.Calls = append(f.Calls, cmd)
+
+	key := fakeKey(cmd.Args)
+	queue, ok := f.responses[key]
+	var response FakeResponse
+	if ok {
+		response = queue[0]
+		if len(queue) > 1 {
+			f.responses[key] = queue[1:]
+		}
+	}
+	fallback := f.Fallback
+	f.mu.Unlock()
+
+	if !ok {
+		if fallback == nil {
+			return &P4Output{}, fmt.Errorf("fake p4: no response scripted for 'p4 %s'", key)
+		}
+		response = fallback(cmd)
+	}
+
+	return &P4Output{Stdout: []byte(response.Stdout), Stderr: []byte(response.Stderr)}, response.Err
+}
+
+// CallCount returns how many times the given arguments were run
+func (f *FakeP4Runner) CallCount(args string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := fakeKey(strings.Fields(args))
+	count := 0
+	for _, call := range f.Calls {
+		if fakeKey(call.Args) == key {
+			count++
+		}
+	}
+	return count
+}
+
+// fakeKey builds the lookup key for a set of arguments
+func fakeKey(args []string) string {
+	return strings.Join(args, " ")
+}
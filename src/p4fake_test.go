@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ============================================================================
+// FIXTURES
+// ============================================================================
+
+// newTestClientView builds the view of client "ws" rooted at root
+func newTestClientView(t *testing.T, root string, caseSensitive bool, lines ...string) *ClientView {
+	t.Helper()
+
+	spec := P4Record{"Client": "ws", "Root": root}
+	for i, line := range lines {
+		spec[fmt.Sprintf("View%d", i)] = line
+	}
+
+	view, err := parseClientView(spec, caseSensitive)
+	if err != nil {
+		t.Fatalf("parseClientView() error = %v", err)
+	}
+	return view
+}
+
+// useClientView makes getClientView return view until the test ends, with an
+// empty local path cache so paths of other tests' workspaces are not reused
+func useClientView(t *testing.T, view *ClientView) {
+	t.Helper()
+
+	sessionClientView.Lock()
+	savedView, savedErr, savedLoaded := sessionClientView.view, sessionClientView.err, sessionClientView.loaded
+	sessionClientView.view, sessionClientView.err, sessionClientView.loaded = view, nil, true
+	sessionClientView.Unlock()
+
+	localPathCache.Lock()
+	savedPaths := localPathCache.paths
+	localPathCache.paths = make(map[string]string)
+	localPathCache.Unlock()
+
+	t.Cleanup(func() {
+		sessionClientView.Lock()
+		sessionClientView.view, sessionClientView.err, sessionClientView.loaded = savedView, savedErr, savedLoaded
+		sessionClientView.Unlock()
+
+		localPathCache.Lock()
+		localPathCache.paths = savedPaths
+		localPathCache.Unlock()
+	})
+}
+
+// setupFakeWorkspace points the scanner at a temporary workspace "ws" mapped
+// from //depot/Game/... and a fake p4 that answers unscripted commands with
+// no output. Returns the workspace root.
+func setupFakeWorkspace(t *testing.T) (string, *FakeP4Runner) {
+	t.Helper()
+
+	root := t.TempDir()
+	useClientView(t, newTestClientView(t, root, true, "//depot/Game/... //ws/..."))
+
+	fake := NewFakeP4Runner()
+	fake.Fallback = func(cmd P4Command) FakeResponse { return FakeResponse{} }
+	previous := setP4Runner(fake)
+	t.Cleanup(func() { setP4Runner(previous) })
+
+	return root, fake
+}
+
+// useTempHome keeps config and per-workspace state files inside a temporary
+// directory
+func useTempHome(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	return home
+}
+
+// writeTestFile creates a file under root, making its directories
+func writeTestFile(t *testing.T, root string, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testPackage builds a package summary for legacy file version -7 (UE4, with
+// a package GUID) or -8 (UE 5.4+, with a saved hash), filling the volatile
+// field with stamp and appending body
+func testPackage(legacyVersion int32, stamp byte, body string) []byte {
+	var buf bytes.Buffer
+	put := func(values ...int32) {
+		for _, value := range values {
+			binary.Write(&buf, binary.LittleEndian, value)
+		}
+	}
+
+	tag := uint32(packageFileTag)
+	put(int32(tag), legacyVersion, 864, 522) // legacy UE3 and UE4 versions
+	if legacyVersion <= -8 {
+		put(ue5VerPackageSavedHash + 1)
+	}
+	put(0) // licensee version
+	if legacyVersion <= -8 {
+		buf.Write(bytes.Repeat([]byte{stamp}, 20)) // SavedHash
+		put(1000)                                  // TotalHeaderSize
+		put(0)                                     // custom versions
+	} else {
+		put(0)    // custom versions
+		put(1000) // TotalHeaderSize
+	}
+	put(5)
+	buf.WriteString("/Gam\x00") // PackageName
+	put(0)                      // flags: editor data kept
+	put(0, 0)                   // names
+	if legacyVersion <= -8 {
+		put(0, 0) // soft object paths
+	}
+	put(0)    // LocalizationId (empty FString)
+	put(0, 0) // gatherable text
+	put(0, 0, 0, 0)
+	if legacyVersion <= -8 {
+		put(0, 0, 0, 0, 0) // verse cells, metadata offset
+	}
+	put(0, 0, 0, 0, 0) // depends, soft package references, searchable names, thumbnails
+	if legacyVersion > -8 {
+		buf.Write(bytes.Repeat([]byte{stamp}, 16)) // Guid
+	}
+	buf.WriteString(body)
+
+	return buf.Bytes()
+}
+
+// scriptHeaderOnlyPackage writes a local package that only differs from its
+// have revision #3 in the package GUID, and scripts the fstat and print calls
+// that compare them
+func scriptHeaderOnlyPackage(t *testing.T, fake *FakeP4Runner, root string, name string) {
+	t.Helper()
+
+	local := testPackage(-7, 1, "same body")
+	localPath := writeTestFile(t, root, name, string(local))
+
+	fake.On("-ztag -x - fstat -Ol -T depotFile,clientFile,haveRev,fileSize", FakeResponse{
+		Stdout: fmt.Sprintf("... depotFile //depot/Game/%s\n... clientFile %s\n... haveRev 3\n... fileSize %d\n", name, localPath, len(local)),
+	})
+	fake.On(fmt.Sprintf("print -q //depot/Game/%s#3", name), FakeResponse{Stdout: string(testPackage(-7, 2, "same body"))})
+}
+
+// ============================================================================
+// FAKE RUNNER
+// ============================================================================
+
+func TestFakeP4Runner(t *testing.T) {
+	fake := NewFakeP4Runner()
+	fake.On("sync -n", FakeResponse{Stdout: "first"})
+	fake.On("sync -n", FakeResponse{Stdout: "second", Stderr: "warn", Err: errors.New("exit status 1")})
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStdout string
+		wantErr    bool
+	}{
+		{"first queued response", []string{"sync", "-n"}, "first", false},
+		{"second queued response", []string{"sync", "-n"}, "second", true},
+		{"last response repeats", []string{"sync", "-n"}, "second", true},
+		{"unscripted command fails", []string{"info"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := fake.Run(context.Background(), P4Command{Args: tt.args})
+			if string(output.Stdout) != tt.wantStdout || (err != nil) != tt.wantErr {
+				t.Errorf("Run(%v) = %q, %v, want %q, error %v", tt.args, output.Stdout, err, tt.wantStdout, tt.wantErr)
+			}
+		})
+	}
+
+	if got := fake.CallCount("sync -n"); got != 3 {
+		t.Errorf("CallCount(sync -n) = %d, want 3", got)
+	}
+}
+
+func TestFakeP4RunnerFallbackAndCancel(t *testing.T) {
+	fake := NewFakeP4Runner()
+	fake.Fallback = func(cmd P4Command) FakeResponse { return FakeResponse{Stdout: cmd.Stdin} }
+	defer setP4Runner(setP4Runner(fake))
+
+	output, err := runP4Input(context.Background(), "//depot/a.txt\n", "-x", "-", "fstat")
+	if err != nil || string(output) != "//depot/a.txt\n" {
+		t.Errorf("runP4Input() = %q, %v, want the fallback's answer", output, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := runP4(ctx, "info"); !errors.Is(err, errCancelled) {
+		t.Errorf("runP4() on a cancelled context error = %v, want errCancelled", err)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// P4Command describes a single p4 invocation
+type P4Command struct {
+	Args  []string // Arguments passed after "p4"
+	Stdin string   // Data written to stdin (e.g. for "p4 -x -")
+	Dir   string   // Working directory, empty for the current one
+	Env   []string // Extra KEY=VALUE entries added to the environment
+}
+
+// P4Output holds everything a p4 invocation wrote
+type P4Output struct {
+	Stdout []byte
+	Stderr []byte
+}
+
+// Combined returns stdout followed by stderr
+func (o *P4Output) Combined() []byte {
+	if o == nil {
+		return nil
+	}
+	combined := make([]byte, 0, len(o.Stdout)+len(o.Stderr))
+	combined = append(combined, o.Stdout...)
+	return append(combined, o.Stderr...)
+}
+
+// P4Runner runs p4 commands. All Perforce access goes through this interface
+// so the CLI can be swapped for a scripted fake (see FakeP4Runner).
+type P4Runner interface {
+	// Run executes the command until it finishes or ctx is done. Output is
+	// returned even when err is non-nil, because p4 reports many non-fatal
+	// conditions through the exit code.
+	Run(ctx context.Context, cmd P4Command) (*P4Output, error)
+}
+
+// p4Runner is the runner used by every p4 call in the program
+var p4Runner P4Runner = &CLIRunner{}
+
+// setP4Runner replaces the active runner and returns the previous one
+func setP4Runner(runner P4Runner) P4Runner {
+	previous := p4Runner
+	p4Runner = runner
+	return previous
+}
+
+// ============================================================================
+// CLI RUNNER
+// ============================================================================
+
+// CLIRunner runs commands through the real p4 executable
+type CLIRunner struct {
+	Path    string        // Executable to run, defaults to "p4" from PATH
+	Timeout time.Duration // Per-command time limit, zero for none
+}
+
+// Run executes p4 and captures stdout and stderr separately
+func (r *CLIRunner) Run(ctx context.Context, p4cmd P4Command) (*P4Output, error) {
+	path := r.Path
+	if path == "" {
+		path = "p4"
+	}
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, path, p4cmd.Args...)
+	cmd.Dir = p4cmd.Dir
+	if len(p4cmd.Env) > 0 {
+		cmd.Env = append(os.Environ(), p4cmd.Env...)
+	}
+	if p4cmd.Stdin != "" {
+		cmd.Stdin = strings.NewReader(p4cmd.Stdin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	output := &P4Output{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+
+	// Report why the process was killed rather than "signal: killed"
+	switch ctx.Err() {
+	case context.Canceled:
+		return output, errCancelled
+	case context.DeadlineExceeded:
+		return output, fmt.Errorf("p4 %s: %w after %s", strings.Join(p4cmd.Args, " "), errTimedOut, r.Timeout)
+	}
+
+	return output, err
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// runP4 runs p4 with the given arguments and returns stdout only
+func runP4(ctx context.Context, args ...string) ([]byte, error) {
+	output, err := p4Runner.Run(ctx, P4Command{Args: args})
+	if output == nil {
+		return nil, err
+	}
+	return output.Stdout, withStderr(output, err)
+}
+
+// runP4Input runs p4 with data piped to stdin and returns stdout only
+func runP4Input(ctx context.Context, stdin string, args ...string) ([]byte, error) {
+	output, err := p4Runner.Run(ctx, P4Command{Args: args, Stdin: stdin})
+	if output == nil {
+		return nil, err
+	}
+	return output.Stdout, withStderr(output, err)
+}
+
+// P4Error is a failed p4 command with what p4 wrote to stderr
+type P4Error struct {
+	Err    error
+	Stderr string
+}
+
+// Error returns the failure followed by p4's messages
+func (e *P4Error) Error() string {
+	return fmt.Sprintf("%v: %s", e.Err, strings.TrimSpace(e.Stderr))
+}
+
+// Unwrap returns the underlying failure
+func (e *P4Error) Unwrap() error {
+	return e.Err
+}
+
+// withStderr attaches stderr to a failed command, so callers that only get
+// stdout can still tell why it failed. Interruptions are returned unchanged.
+func withStderr(output *P4Output, err error) error {
+	if err == nil || errors.Is(err, errCancelled) || errors.Is(err, errTimedOut) || len(bytes.TrimSpace(output.Stderr)) == 0 {
+		return err
+	}
+	return &P4Error{Err: err, Stderr: string(output.Stderr)}
+}
+
+// p4NoMatchMessages are p4 errors that only mean no file matched the arguments
+var p4NoMatchMessages = []string{
+	"file(s) not opened on this client",
+	"file(s) not opened anywhere",
+	"no such file(s)",
+	"file(s) not in client view",
+	"no file(s) to resolve",
+	"no file(s) to reconcile",
+	"file(s) not on client",
+}
+
+// isNoMatch reports whether a failed p4 command only said that nothing
+// matched its arguments, which p4 reports with a non-zero exit code. Any
+// other message (server down, expired ticket...) is a real failure.
+func isNoMatch(err error) bool {
+	var p4Err *P4Error
+	if !errors.As(err, &p4Err) {
+		return false
+	}
+
+	matched := false
+	for _, line := range strings.Split(p4Err.Stderr, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" {
+			continue
+		}
+		known := false
+		for _, message := range p4NoMatchMessages {
+			if strings.Contains(line, message) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// runP4Combined runs p4 and returns stdout and stderr together
+func runP4Combined(ctx context.Context, args ...string) ([]byte, error) {
+	output, err := p4Runner.Run(ctx, P4Command{Args: args})
+	return output.Combined(), err
+}
+
+// runP4CombinedIn runs p4 from the given working directory and returns stdout and stderr together
+func runP4CombinedIn(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	output, err := p4Runner.Run(ctx, P4Command{Args: args, Dir: dir})
+	return output.Combined(), err
+}
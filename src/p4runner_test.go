@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsNoMatch(t *testing.T) {
+	exit1 := errors.New("exit status 1")
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain error", exit1, false},
+		{"nothing opened", &P4Error{Err: exit1, Stderr: "File(s) not opened on this client.\n"}, true},
+		{"path with message", &P4Error{Err: exit1, Stderr: "//depot/x/... - no such file(s).\n"}, true},
+		{"several no-match lines", &P4Error{Err: exit1, Stderr: "a - no such file(s).\nb - file(s) not in client view.\n"}, true},
+		{"server down", &P4Error{Err: exit1, Stderr: "Connect to server failed; check $P4PORT.\n"}, false},
+		{"no match mixed with a real error", &P4Error{Err: exit1, Stderr: "a - no such file(s).\nYour session has expired, please login again.\n"}, false},
+		{"wrapped", fmt.Errorf("opened: %w", &P4Error{Err: exit1, Stderr: "file(s) not opened on this client."}), true},
+		{"cancelled", errCancelled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNoMatch(tt.err); got != tt.want {
+				t.Errorf("isNoMatch(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunP4AttachesStderr(t *testing.T) {
+	fake := NewFakeP4Runner()
+	fake.On("opened", FakeResponse{Stderr: "File(s) not opened on this client.\n", Err: errors.New("exit status 1")})
+	fake.On("info", FakeResponse{Err: errCancelled, Stderr: "ignored"})
+	defer setP4Runner(setP4Runner(fake))
+
+	_, err := runP4(context.Background(), "opened")
+	var p4Err *P4Error
+	if !errors.As(err, &p4Err) || !isNoMatch(err) {
+		t.Errorf("runP4(opened) error = %v, want a no-match *P4Error", err)
+	}
+
+	if _, err := runP4(context.Background(), "info"); err != errCancelled {
+		t.Errorf("runP4(info) error = %v, want errCancelled unchanged", err)
+	}
+}
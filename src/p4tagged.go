@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+)
+
+// P4Record is one record of tagged (-ztag) output, keyed by field name
+type P4Record map[string]string
+
+// FileRecord is the typed form of a file-level tagged record as reported by
+// reconcile, opened, diff, fstat and where
+type FileRecord struct {
+	DepotFile  string
+	ClientFile string
+	LocalPath  string
+	Action     string
+	Change     string
+	Type       string
+	Digest     string // MD5 of the have revision (fstat -Ol)
+	FileSize   int64  // size of the have revision (fstat -Ol)
+	MovedFile  string // other half of a move/add or move/delete
+	Rev        int
+	HaveRev    int
+	HeadRev    int
+}
+
+// ============================================================================
+// DECODING
+// ============================================================================
+
+// parseTaggedOutput decodes "... key value" lines into records.
+// Records are separated by blank lines; untagged lines that follow a field are
+// treated as continuation lines of that field (e.g. multi-line descriptions).
+func parseTaggedOutput(output []byte) []P4Record {
+	var records []P4Record
+	var current P4Record
+	lastKey := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.HasPrefix(line, "... ") {
+			keyValue := strings.TrimPrefix(line, "... ")
+			key, value, _ := strings.Cut(keyValue, " ")
+			if current == nil {
+				current = P4Record{}
+			} else if _, exists := current[key]; exists {
+				// A repeated key without a blank separator starts a new record
+				records = append(records, current)
+				current = P4Record{}
+			}
+			current[key] = value
+			lastKey = key
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if current != nil {
+				records = append(records, current)
+				current = nil
+			}
+			lastKey = ""
+			continue
+		}
+
+		if current != nil && lastKey != "" {
+			current[lastKey] += "\n" + line
+		}
+	}
+
+	if current != nil {
+		records = append(records, current)
+	}
+
+	return records
+}
+
+// Int returns the named field as an integer, or 0 if it is missing or not numeric
+func (r P4Record) Int(key string) int {
+	value, err := strconv.Atoi(strings.TrimSpace(r[key]))
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// fileRecordFrom converts a raw tagged record into a FileRecord
+func fileRecordFrom(r P4Record) FileRecord {
+	record := FileRecord{
+		DepotFile:  r["depotFile"],
+		ClientFile: r["clientFile"],
+		LocalPath:  r["path"],
+		Action:     r["action"],
+		Change:     r["change"],
+		Type:       r["type"],
+		Digest:     r["digest"],
+		MovedFile:  r["movedFile"],
+		Rev:        r.Int("rev"),
+		HaveRev:    r.Int("haveRev"),
+		HeadRev:    r.Int("headRev"),
+	}
+
+	// reconcile, diff and fstat report clientFile in local syntax
+	if record.LocalPath == "" && record.ClientFile != "" && !strings.HasPrefix(record.ClientFile, "//") {
+		record.LocalPath = record.ClientFile
+	}
+	if size, err := strconv.ParseInt(r["fileSize"], 10, 64); err == nil {
+		record.FileSize = size
+	}
+	if record.Type == "" {
+		record.Type = r["headType"]
+	}
+	if record.Rev == 0 {
+		record.Rev = r.Int("workRev")
+	}
+
+	return record
+}
+
+// ============================================================================
+// TAGGED COMMANDS
+// ============================================================================
+
+// runP4Tagged runs "p4 -ztag <args>" and decodes the records
+func runP4Tagged(ctx context.Context, args ...string) ([]P4Record, error) {
+	output, err := runP4(ctx, append([]string{"-ztag"}, args...)...)
+	return parseTaggedOutput(output), err
+}
+
+// runP4TaggedArgFile runs "p4 -ztag -x - <args>", passing one argument per
+// line on stdin, and decodes the records
+func runP4TaggedArgFile(ctx context.Context, lines []string, args ...string) ([]P4Record, error) {
+	stdin := strings.Join(lines, "\n") + "\n"
+	output, err := runP4Input(ctx, stdin, append([]string{"-ztag", "-x", "-"}, args...)...)
+	return parseTaggedOutput(output), err
+}
+
+// runP4TaggedFiles runs a tagged command and returns its file records
+func runP4TaggedFiles(ctx context.Context, args ...string) ([]FileRecord, error) {
+	records, err := runP4Tagged(ctx, args...)
+
+	var files []FileRecord
+	for _, r := range records {
+		if r["depotFile"] == "" && r["clientFile"] == "" {
+			continue
+		}
+		files = append(files, fileRecordFrom(r))
+	}
+
+	return files, err
+}
+
+// p4ReconcilePreview runs "p4 reconcile -n" on a path
+func p4ReconcilePreview(ctx context.Context, path string) ([]FileRecord, error) {
+	return runP4TaggedFiles(ctx, "reconcile", "-n", path)
+}
+
+// p4Opened runs "p4 opened" with optional extra arguments
+func p4Opened(ctx context.Context, args ...string) ([]FileRecord, error) {
+	return runP4TaggedFiles(ctx, append([]string{"opened"}, args...)...)
+}
+
+// p4DiffStatus runs "p4 diff -s<flag>" (e.g. "e" or "r") with an optional scope
+func p4DiffStatus(ctx context.Context, flag string, scopePath string) ([]FileRecord, error) {
+	args := []string{"diff", "-s" + flag}
+	if scopePath != "" {
+		args = append(args, scopePath)
+	}
+	return runP4TaggedFiles(ctx, args...)
+}
+
+// p4Fstat runs "p4 fstat" with the given flags and file arguments
+func p4Fstat(ctx context.Context, args ...string) ([]FileRecord, error) {
+	return runP4TaggedFiles(ctx, append([]string{"fstat"}, args...)...)
+}
+
+// p4FstatDigests runs "p4 fstat -Ol -Rh" on path#have, returning the server
+// digest and size of every have revision in it. Without #have, fstat would
+// report the head revision, making files behind head look modified.
+func p4FstatDigests(ctx context.Context, path string) ([]FileRecord, error) {
+	return p4Fstat(ctx, "-Ol", "-Rh", "-T", "depotFile,clientFile,headType,haveRev,action,fileSize,digest", path+"#have")
+}
+
+// p4Where runs "p4 where" and returns the mapped (non-excluded) records
+func p4Where(ctx context.Context, paths ...string) ([]FileRecord, error) {
+	records, err := runP4Tagged(ctx, append([]string{"where"}, paths...)...)
+
+	var files []FileRecord
+	for _, r := range records {
+		if _, unmapped := r["unmap"]; unmapped || r["depotFile"] == "" {
+			continue
+		}
+		files = append(files, fileRecordFrom(r))
+	}
+
+	return files, err
+}
+
+// p4InfoRecord runs "p4 info" and returns its single record
+func p4InfoRecord(ctx context.Context) (P4Record, error) {
+	records, err := runP4Tagged(ctx, "info")
+	if len(records) == 0 {
+		return P4Record{}, err
+	}
+	return records[0], err
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTaggedOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []P4Record
+	}{
+		{
+			name:   "empty",
+			output: "",
+			want:   nil,
+		},
+		{
+			name:   "records separated by blank lines",
+			output: "... depotFile //depot/a.txt\n... action edit\n\n... depotFile //depot/b.txt\n... action add\n",
+			want: []P4Record{
+				{"depotFile": "//depot/a.txt", "action": "edit"},
+				{"depotFile": "//depot/b.txt", "action": "add"},
+			},
+		},
+		{
+			name:   "repeated key starts a new record",
+			output: "... depotFile //depot/a.txt\n... depotFile //depot/b.txt\n",
+			want: []P4Record{
+				{"depotFile": "//depot/a.txt"},
+				{"depotFile": "//depot/b.txt"},
+			},
+		},
+		{
+			name:   "continuation lines join the previous field",
+			output: "... change 12\n... desc first line\nsecond line\n",
+			want: []P4Record{
+				{"change": "12", "desc": "first line\nsecond line"},
+			},
+		},
+		{
+			name:   "CRLF line endings",
+			output: "... depotFile //depot/a.txt\r\n... haveRev 3\r\n\r\n",
+			want: []P4Record{
+				{"depotFile": "//depot/a.txt", "haveRev": "3"},
+			},
+		},
+		{
+			name:   "field without a value",
+			output: "... depotFile //depot/a.txt\n... isMapped\n",
+			want: []P4Record{
+				{"depotFile": "//depot/a.txt", "isMapped": ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTaggedOutput([]byte(tt.output))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTaggedOutput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileRecordFrom(t *testing.T) {
+	tests := []struct {
+		name   string
+		record P4Record
+		want   FileRecord
+	}{
+		{
+			name:   "local clientFile becomes the local path",
+			record: P4Record{"depotFile": "//depot/a.txt", "clientFile": "/ws/a.txt", "haveRev": "2", "fileSize": "10"},
+			want:   FileRecord{DepotFile: "//depot/a.txt", ClientFile: "/ws/a.txt", LocalPath: "/ws/a.txt", HaveRev: 2, FileSize: 10},
+		},
+		{
+			name:   "client syntax clientFile is not a local path",
+			record: P4Record{"depotFile": "//depot/a.txt", "clientFile": "//ws/a.txt"},
+			want:   FileRecord{DepotFile: "//depot/a.txt", ClientFile: "//ws/a.txt"},
+		},
+		{
+			name:   "headType and workRev fill in missing fields",
+			record: P4Record{"depotFile": "//depot/a.txt", "headType": "binary+l", "workRev": "4"},
+			want:   FileRecord{DepotFile: "//depot/a.txt", Type: "binary+l", Rev: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fileRecordFrom(tt.record); got != tt.want {
+				t.Errorf("fileRecordFrom() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+)
+
+// localPathCache remembers depot-to-local translations for the whole session,
+// so repeated scans never ask the server about the same file twice
+var localPathCache = struct {
+	sync.Mutex
+	paths map[string]string
+}{paths: make(map[string]string)}
+
+// cachedLocalPath returns a previously resolved local path
+func cachedLocalPath(depotPath string) (string, bool) {
+	localPathCache.Lock()
+	defer localPathCache.Unlock()
+
+	localPath, ok := localPathCache.paths[depotPath]
+	return localPath, ok
+}
+
+// cacheLocalPath stores a resolved local path
+func cacheLocalPath(depotPath string, localPath string) {
+	if depotPath == "" || localPath == "" {
+		return
+	}
+
+	localPathCache.Lock()
+	defer localPathCache.Unlock()
+
+	localPathCache.paths[depotPath] = filepath.Clean(localPath)
+}
+
+// resolveLocalPaths fills in LocalPath for every record, using the cache, the
+// local client view and a single batched "p4 -x - where" for whatever is left
+func resolveLocalPaths(ctx context.Context, records []FileRecord) {
+	var missing []string
+	seen := make(map[string]bool)
+	view, _ := getClientView(ctx)
+
+	for i := range records {
+		record := &records[i]
+		if record.LocalPath != "" {
+			cacheLocalPath(record.DepotFile, record.LocalPath)
+			continue
+		}
+		if localPath, ok := cachedLocalPath(record.DepotFile); ok {
+			record.LocalPath = localPath
+			continue
+		}
+		if view != nil {
+			if localPath, ok := view.DepotToLocal(record.DepotFile); ok {
+				record.LocalPath = localPath
+				cacheLocalPath(record.DepotFile, localPath)
+				continue
+			}
+		}
+		if record.DepotFile != "" && !seen[record.DepotFile] {
+			seen[record.DepotFile] = true
+			missing = append(missing, record.DepotFile)
+		}
+	}
+
+	if len(missing) == 0 {
+		return
+	}
+
+	whereRecords, _ := runP4TaggedArgFile(ctx, missing, "where")
+	for _, r := range whereRecords {
+		if _, unmapped := r["unmap"]; unmapped {
+			continue
+		}
+		cacheLocalPath(r["depotFile"], r["path"])
+	}
+
+	for i := range records {
+		record := &records[i]
+		if record.LocalPath == "" {
+			record.LocalPath, _ = cachedLocalPath(record.DepotFile)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveLocalPaths(t *testing.T) {
+	root, fake := setupFakeWorkspace(t)
+	elsewhere := filepath.Join(t.TempDir(), "Shared", "x.ini")
+	fake.On("-ztag -x - where", FakeResponse{Stdout: "... depotFile //depot/Shared/x.ini\n... clientFile //ws/Shared/x.ini\n... path " + elsewhere + "\n\n"})
+
+	records := []FileRecord{
+		{DepotFile: "//depot/Game/Source/Hero.cpp"},
+		{DepotFile: "//depot/Shared/x.ini"},
+		{DepotFile: "//depot/Shared/x.ini"},
+		{DepotFile: "//depot/Game/a.txt", LocalPath: filepath.Join(root, "given", "a.txt")},
+	}
+	resolveLocalPaths(context.Background(), records)
+
+	want := []string{
+		filepath.Join(root, "Source", "Hero.cpp"),
+		elsewhere,
+		elsewhere,
+		filepath.Join(root, "given", "a.txt"),
+	}
+	for i, record := range records {
+		if record.LocalPath != want[i] {
+			t.Errorf("records[%d].LocalPath = %q, want %q", i, record.LocalPath, want[i])
+		}
+	}
+
+	if len(fake.Calls) != 1 || fake.Calls[0].Stdin != "//depot/Shared/x.ini\n" {
+		t.Fatalf("calls = %+v, want one batched where for the unmapped file", fake.Calls)
+	}
+
+	// A second pass is answered from the session cache
+	again := []FileRecord{{DepotFile: "//depot/Shared/x.ini"}, {DepotFile: "//depot/Game/a.txt"}}
+	resolveLocalPaths(context.Background(), again)
+	if again[0].LocalPath != elsewhere || again[1].LocalPath != want[3] || len(fake.Calls) != 1 {
+		t.Errorf("second pass = %+v with %d call(s), want cached paths and no new call", again, len(fake.Calls))
+	}
+}
+
+func TestScanForModifiedFilesScopedLocalPaths(t *testing.T) {
+	root, fake := setupFakeWorkspace(t)
+	fake.On("-ztag diff -se", FakeResponse{Stdout: "... depotFile //depot/Game/Source/Hero.cpp\n\n"})
+
+	result, err := ScanForModifiedFilesScoped(context.Background(), nil, "", false, true, false, false, false, false)
+	if err != nil {
+		t.Fatalf("ScanForModifiedFilesScoped() error = %v", err)
+	}
+
+	want := filepath.Join(root, "Source", "Hero.cpp")
+	if len(result.OpenedWithChanges) != 1 || result.OpenedWithChanges[0].Path != want {
+		t.Errorf("OpenedWithChanges = %+v, want local path %s from the client view", result.OpenedWithChanges, want)
+	}
+	if fake.CallCount("-ztag -x - where") != 0 {
+		t.Error("ran p4 where for a path the client view maps")
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SubmitAlert is a newer revision someone submitted of a file we have opened
+type SubmitAlert struct {
+	DepotFile string
+	LocalPath string
+	Revision  RevisionInfo
+	SeenAt    time.Time
+}
+
+// SubmitPoller periodically checks "p4 changes" under the folders of our
+// opened files and raises an alert when someone submits a newer revision of
+// one of them
+type SubmitPoller struct {
+	Interval time.Duration
+	Started  time.Time
+
+	mu         sync.Mutex
+	lastChange int           // newest submitted change already looked at
+	alerts     []SubmitAlert // unacknowledged, newest first
+	lastPoll   time.Time
+	lastErr    error
+	stop       context.CancelFunc
+}
+
+// activePoller is the running submit poller, or nil when polling is off
+var activePoller *SubmitPoller
+
+// startSubmitPoller starts polling in the background every interval
+func startSubmitPoller(interval time.Duration) (*SubmitPoller, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Only submits made from now on raise alerts
+	records, err := runP4Tagged(ctx, "changes", "-m", "1", "-s", "submitted")
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to read the latest change: %v", err)
+	}
+
+	poller := &SubmitPoller{Interval: interval, Started: time.Now(), stop: cancel}
+	if len(records) > 0 {
+		poller.lastChange = records[0].Int("change")
+	}
+
+	go poller.run(ctx)
+	return poller, nil
+}
+
+// startActivePoller starts the submit poller shown in the menus
+func startActivePoller(interval time.Duration) error {
+	poller, err := startSubmitPoller(interval)
+	if err != nil {
+		return fmt.Errorf("failed to start submit poller: %v", err)
+	}
+	activePoller = poller
+	return nil
+}
+
+// stopActivePoller stops the submit poller, if one is running
+func stopActivePoller() {
+	activePoller.Stop()
+	activePoller = nil
+}
+
+// Stop stops polling; the poller can't be used afterwards
+func (p *SubmitPoller) Stop() {
+	if p == nil || p.stop == nil {
+		return
+	}
+	p.stop()
+	p.stop = nil
+}
+
+// run polls until ctx is cancelled
+func (p *SubmitPoller) run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll(ctx)
+		}
+	}
+}
+
+// poll checks for new submits under the folders of our opened files and
+// updates the alerts
+func (p *SubmitPoller) poll(ctx context.Context) {
+	p.mu.Lock()
+	since := p.lastChange + 1
+	p.mu.Unlock()
+
+	newest, stale, newChanges, err := p.pollOnce(ctx, since)
+	if ctx.Err() != nil {
+		// Stopped while polling; nothing to report
+		return
+	}
+	p.update(newest, stale, newChanges, err)
+}
+
+// pollOnce runs one poll for changes from since on. It returns the newest
+// submitted change seen, our out-of-date opened files and the new changes.
+// stale is nil when no file needed checking.
+func (p *SubmitPoller) pollOnce(ctx context.Context, since int) (int, []ModifiedFile, map[string]bool, error) {
+	opened, err := p4Opened(ctx)
+	if err != nil && !isNoMatch(err) {
+		return 0, nil, nil, fmt.Errorf("p4 opened: %v", err)
+	}
+	if len(opened) == 0 {
+		// Nothing opened means nothing to warn about
+		return since - 1, nil, nil, nil
+	}
+
+	var lines []string
+	for _, dir := range openedFolders(opened) {
+		lines = append(lines, fmt.Sprintf("%s/...@%d,@now", dir, since))
+	}
+
+	newChanges := make(map[string]bool)
+	newest := since - 1
+	for _, chunk := range chunkFiles(lines, batchMaxFiles, batchMaxBytes) {
+		// Folders without new submits make p4 exit non-zero
+		records, err := runP4TaggedArgFile(ctx, chunk, "changes", "-s", "submitted")
+		if err != nil && !isNoMatch(err) {
+			return 0, nil, nil, fmt.Errorf("p4 changes: %v", err)
+		}
+		for _, r := range records {
+			if change := r.Int("change"); change >= since {
+				newChanges[r["change"]] = true
+				if change > newest {
+					newest = change
+				}
+			}
+		}
+	}
+
+	p.mu.Lock()
+	hadAlerts := len(p.alerts) > 0
+	p.mu.Unlock()
+	if len(newChanges) == 0 && !hadAlerts {
+		return newest, nil, nil, nil
+	}
+
+	// Which of our files the new changes made out of date
+	stale, err := getOpenedFilesOutOfDate(ctx, "", false)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("checking opened files: %v", err)
+	}
+	return newest, stale, newChanges, nil
+}
+
+// update records the outcome of a poll. stale is nil when no file needed
+// checking, which keeps no alerts. A failed poll only records err, keeping
+// the alerts and the last change seen so the next poll retries.
+func (p *SubmitPoller) update(newest int, stale []ModifiedFile, newChanges map[string]bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastPoll = time.Now()
+	p.lastErr = err
+	if err != nil {
+		return
+	}
+	if newest > p.lastChange {
+		p.lastChange = newest
+	}
+
+	staleFiles := make(map[string]bool)
+	for _, file := range stale {
+		staleFiles[file.DepotPath] = true
+	}
+
+	var kept []SubmitAlert
+	for _, alert := range p.alerts {
+		if staleFiles[alert.DepotFile] {
+			kept = append(kept, alert)
+		}
+	}
+
+	for _, file := range stale {
+		for _, revision := range file.NewerRevisions {
+			if newChanges[revision.Change] {
+				kept = append(kept, SubmitAlert{DepotFile: file.DepotPath, LocalPath: file.Path, Revision: revision, SeenAt: p.lastPoll})
+			}
+		}
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		left, _ := strconv.Atoi(kept[i].Revision.Change)
+		right, _ := strconv.Atoi(kept[j].Revision.Change)
+		return left > right
+	})
+	p.alerts = kept
+}
+
+// openedFolders returns the depot folders of opened files, leaving out
+// folders inside another one in the list
+func openedFolders(opened []FileRecord) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, record := range opened {
+		dir := path.Dir(record.DepotFile)
+		if record.DepotFile != "" && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+
+	var folders []string
+	for _, dir := range dirs {
+		if len(folders) > 0 && strings.HasPrefix(dir, folders[len(folders)-1]+"/") {
+			continue
+		}
+		folders = append(folders, dir)
+	}
+	return folders
+}
+
+// Alerts returns the unacknowledged alerts, newest first
+func (p *SubmitPoller) Alerts() []SubmitAlert {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]SubmitAlert(nil), p.alerts...)
+}
+
+// Acknowledge clears the current alerts; later submits raise new ones
+func (p *SubmitPoller) Acknowledge() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.alerts = nil
+}
+
+// Status describes the poller for menus
+func (p *SubmitPoller) Status() string {
+	if p == nil {
+		return "stopped"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := fmt.Sprintf("every %s since %s, last change @%d", p.Interval, p.Started.Format("15:04:05"), p.lastChange)
+	if p.lastErr != nil {
+		status += fmt.Sprintf(", last poll failed: %v", p.lastErr)
+	} else if !p.lastPoll.IsZero() {
+		status += ", last poll " + p.lastPoll.Format("15:04:05")
+	}
+	return status
+}
+
+// ============================================================================
+// DISPLAY
+// ============================================================================
+
+// printSubmitAlerts prints a prominent banner for unacknowledged alerts,
+// listing up to limit of them
+func printSubmitAlerts(limit int) {
+	alerts := activePoller.Alerts()
+	if len(alerts) == 0 {
+		return
+	}
+
+	fmt.Println("\n╔════════════════════════════════════════════════════════════╗")
+	fmt.Printf("║  🚨 %-55s║\n", fmt.Sprintf("%d NEW SUBMIT(S) TOUCH FILES YOU HAVE OPEN", len(alerts)))
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	for i, alert := range alerts {
+		if i >= limit {
+			fmt.Printf("  ... and %d more (see View Changes)\n", len(alerts)-limit)
+			break
+		}
+		fmt.Printf("  • %s#%d by %s in %s (%s): %s\n",
+			alert.DepotFile,
+			alert.Revision.Rev,
+			alert.Revision.User,
+			alert.Revision.Change,
+			alert.SeenAt.Format("15:04"),
+			truncate(firstLine(alert.Revision.Desc), 40))
+	}
+	fmt.Println("  Sync and resolve these before submitting (main menu 6, scan for out-of-date files).")
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveInfo describes the pending resolve of an opened file
+type ResolveInfo struct {
+	FromFile     string // depot file whose changes are being resolved in
+	StartFromRev int
+	EndFromRev   int
+	ResolveType  string // "content", "branch", "delete", "move", "filetype"...
+	ContentType  string // e.g. "3waytext", "2wayraw"
+}
+
+// resolveActions maps the screen's commands to "p4 resolve" flags
+var resolveActions = []struct {
+	Command string
+	Flag    string
+	Label   string
+}{
+	{"ay", "-ay", "accept yours (keep your version, discard theirs)"},
+	{"at", "-at", "accept theirs (discard YOUR edits)"},
+	{"am", "-am", "accept merged (auto-merge, skips files with conflicts)"},
+	{"as", "-as", "safe auto-merge (only files changed on one side)"},
+}
+
+// ============================================================================
+// DETECTION
+// ============================================================================
+
+// getUnresolvedFiles returns opened files with a pending resolve (p4 resolve -n)
+func getUnresolvedFiles(ctx context.Context, scopePath string, verbose bool) ([]ModifiedFile, error) {
+	args := []string{"resolve", "-n"}
+	if scopePath != "" {
+		args = append(args, scopePath)
+	}
+	if verbose {
+		fmt.Printf("  Executing: p4 -ztag %s\n", strings.Join(args, " "))
+	}
+
+	// resolve -n exits non-zero when there is nothing to resolve
+	records, err := runP4Tagged(ctx, args...)
+	if err != nil && !isNoMatch(err) {
+		return []ModifiedFile{}, err
+	}
+
+	view, _ := getClientView(ctx)
+
+	// A file can need several resolves (content, filetype...); content comes first
+	files := []ModifiedFile{}
+	seen := make(map[string]bool)
+	for _, r := range records {
+		localPath := r["clientFile"]
+		if localPath == "" || seen[localPath] {
+			continue
+		}
+		seen[localPath] = true
+		localPath = filepath.Clean(localPath)
+
+		details := FileDetails{LocalPath: localPath}
+		if view != nil {
+			details.DepotPath, _ = view.LocalToDepot(localPath)
+		}
+
+		files = append(files, ModifiedFile{
+			Path:     localPath,
+			Action:   "resolve",
+			IsOpened: true,
+			Resolve: &ResolveInfo{
+				FromFile:     r["fromFile"],
+				StartFromRev: r.Int("startFromRev"),
+				EndFromRev:   r.Int("endFromRev"),
+				ResolveType:  r["resolveType"],
+				ContentType:  r["contentResolveType"],
+			},
+			FileDetails: details,
+		})
+	}
+
+	return files, nil
+}
+
+// isUnmergeable reports whether a file is a binary (UE) asset that p4 can't
+// merge, so only accepting one side whole is possible
+func isUnmergeable(file ModifiedFile) bool {
+	if file.Resolve != nil && strings.HasSuffix(file.Resolve.ContentType, "raw") {
+		return true
+	}
+
+	switch strings.ToLower(filepath.Ext(file.Path)) {
+	case ".uasset", ".umap":
+		return true
+	}
+
+	base, _, _ := strings.Cut(file.FileType, "+")
+	switch base {
+	case "binary", "xbinary", "ubinary", "uxbinary":
+		return true
+	}
+	return false
+}
+
+// FromLabel describes where the changes being resolved come from
+func (r *ResolveInfo) FromLabel() string {
+	if r == nil || r.FromFile == "" {
+		return "-"
+	}
+	if r.StartFromRev > 0 && r.StartFromRev+1 < r.EndFromRev {
+		return fmt.Sprintf("%s#%d,#%d", r.FromFile, r.StartFromRev+1, r.EndFromRev)
+	}
+	return fmt.Sprintf("%s#%d", r.FromFile, r.EndFromRev)
+}
+
+// ============================================================================
+// DISPLAY
+// ============================================================================
+
+// printResolveTable prints files needing resolve, numbered, up to limit rows
+func printResolveTable(files []ModifiedFile, limit int) {
+	fmt.Printf("  %-4s %-6s %-9s %-14s  %s\n", "#", "KIND", "RESOLVE", "TYPE", "PATH")
+	fmt.Printf("  %-4s %-6s %-9s %-14s  %s\n", "─", "────", "───────", "────", "────")
+
+	for i, file := range files {
+		if i >= limit {
+			fmt.Printf("  ... and %d more\n", len(files)-limit)
+			break
+		}
+
+		kind := "text"
+		if isUnmergeable(file) {
+			kind = "BINARY"
+		}
+		resolveType := "-"
+		if file.Resolve != nil && file.Resolve.ResolveType != "" {
+			resolveType = file.Resolve.ResolveType
+		}
+
+		fmt.Printf("  %-4d %-6s %-9s %-14s  %s\n", i+1, kind, resolveType, truncate(file.TypeLabel(), 14), file.Path)
+		fmt.Printf("  %-4s └ theirs: %s\n", "", file.Resolve.FromLabel())
+	}
+}
+
+// ============================================================================
+// RESOLVE SCREEN
+// ============================================================================
+
+// showResolveScreen lists files needing resolve and accepts yours, theirs or
+// the merged result per file or in bulk
+func showResolveScreen(ctx context.Context, reader *bufio.Reader) {
+	for {
+		if ctx.Err() != nil {
+			fmt.Println("Cancelled.")
+			return
+		}
+
+		fmt.Println("\n─────────────────────────────────────")
+		fmt.Println("RESOLVE FILES")
+		fmt.Println("─────────────────────────────────────")
+
+		files, err := getUnresolvedFiles(ctx, "", false)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if len(files) == 0 {
+			fmt.Println("✓ Nothing to resolve.")
+			return
+		}
+		enrichModifiedFiles(ctx, files)
+
+		binaries := 0
+		for _, file := range files {
+			if isUnmergeable(file) {
+				binaries++
+			}
+		}
+
+		fmt.Printf("%d file(s) need resolving:\n\n", len(files))
+		printResolveTable(files, len(files))
+
+		if binaries > 0 {
+			fmt.Println()
+			fmt.Printf("⛔ %d BINARY asset(s) (.uasset/.umap) CANNOT be merged.\n", binaries)
+			fmt.Println("   You must keep either your version or theirs - the other side's work is lost.")
+			fmt.Println("   Talk to whoever submitted before accepting theirs over your own edits.")
+		}
+
+		fmt.Println()
+		fmt.Println("Commands (<command> <files>, files as 1 / 1,3,5-8 / all):")
+		for _, action := range resolveActions {
+			fmt.Printf("  %-3s - %s\n", action.Command, action.Label)
+		}
+		fmt.Println("  r   - Refresh")
+		fmt.Println("  q   - Back")
+		fmt.Print("\nEnter command: ")
+
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(strings.ToLower(input))
+
+		if input == "q" || input == "" {
+			return
+		}
+		if input == "r" {
+			continue
+		}
+
+		command, selection, _ := strings.Cut(input, " ")
+		flag := ""
+		for _, action := range resolveActions {
+			if action.Command == command {
+				flag = action.Flag
+			}
+		}
+		if flag == "" {
+			fmt.Println("Invalid command.")
+			continue
+		}
+
+		selected := parseFileSelection(selection, files)
+		if len(selected) == 0 {
+			fmt.Println("No valid files selected.")
+			continue
+		}
+
+		if !confirmResolve(command, flag, selected, reader) {
+			fmt.Println("Cancelled.")
+			continue
+		}
+
+		var paths []string
+		for _, file := range selected {
+			paths = append(paths, file.Path)
+		}
+
+		fmt.Println("\nResolving files...")
+		result := runP4Batch(ctx, paths, "resolve", flag)
+		printBatchSummary(result)
+
+		fmt.Print("\nPress Enter to continue...")
+		reader.ReadString('\n')
+	}
+}
+
+// confirmResolve shows what a resolve will do, warning about binary assets,
+// and asks for confirmation. Accepting theirs needs a typed YES.
+func confirmResolve(command string, flag string, files []ModifiedFile, reader *bufio.Reader) bool {
+	var binaries []ModifiedFile
+	for _, file := range files {
+		if isUnmergeable(file) {
+			binaries = append(binaries, file)
+		}
+	}
+
+	fmt.Printf("\np4 resolve %s on %d file(s)\n", flag, len(files))
+
+	if len(binaries) > 0 {
+		fmt.Println("\n═══════════════════════════════════════════════════════════════")
+		switch command {
+		case "at":
+			fmt.Printf("⛔ %d binary asset(s) will be REPLACED by their version.\n", len(binaries))
+			fmt.Println("   Your edits to these files will be LOST - they cannot be merged.")
+		case "ay":
+			fmt.Printf("⛔ %d binary asset(s) will keep YOUR version.\n", len(binaries))
+			fmt.Println("   The newer submitted changes to these files will be overwritten when you submit.")
+		default:
+			fmt.Printf("⛔ %d binary asset(s) cannot be merged - p4 will skip them.\n", len(binaries))
+			fmt.Println("   Use 'ay' or 'at' for these files.")
+		}
+		fmt.Println("═══════════════════════════════════════════════════════════════")
+		for i, file := range binaries {
+			if i < 10 {
+				fmt.Printf("  • %s\n", file.Path)
+			}
+		}
+		if len(binaries) > 10 {
+			fmt.Printf("  ... and %d more\n", len(binaries)-10)
+		}
+	}
+
+	if command == "at" {
+		fmt.Print("\nType 'YES' to discard your edits and accept theirs: ")
+		confirm, _ := reader.ReadString('\n')
+		return strings.TrimSpace(confirm) == "YES"
+	}
+
+	fmt.Print("\nProceed? (y/n): ")
+	confirm, _ := reader.ReadString('\n')
+	confirm = strings.TrimSpace(strings.ToLower(confirm))
+	return confirm == "y" || confirm == "yes"
+}
+
+// offerResolveScreen asks whether to open the resolve screen now
+func offerResolveScreen(ctx context.Context, reader *bufio.Reader) {
+	fmt.Print("\nOpen the resolve screen now? (y/n): ")
+	confirm, _ := reader.ReadString('\n')
+	confirm = strings.TrimSpace(strings.ToLower(confirm))
+	if confirm == "y" || confirm == "yes" {
+		showResolveScreen(ctx, reader)
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ScanCacheEntry is what the last scan learned about one local file
+type ScanCacheEntry struct {
+	Size       int64  `json:"size"`
+	ModTime    int64  `json:"mtime"`                 // UnixNano
+	Digest     string `json:"digest,omitempty"`      // local MD5, if it was computed
+	HaveDigest string `json:"have_digest,omitempty"` // server digest it was compared with
+	Status     string `json:"status"`                // "same", or the action p4 would take ("edit", "add", "delete"...)
+}
+
+// ScanCache remembers per-file scan results for one workspace so repeat
+// scans only re-examine files whose size or mtime changed: digest scans
+// skip hashing them, reconcile scans skip sending them to the server
+type ScanCache struct {
+	mu    sync.Mutex
+	path  string
+	dirty bool
+	Files map[string]ScanCacheEntry `json:"files"`
+
+	// Reconciled is what the last reconcile scans reported per local file
+	// (deletes have no local file and a size of -1); Folders lists the
+	// folders whose every file they cover
+	Reconciled map[string]ScanCacheEntry `json:"reconciled,omitempty"`
+	Folders    []string                  `json:"folders,omitempty"`
+}
+
+// getScanCachePath returns the cache file for a workspace, stored next to
+// the config file
+func getScanCachePath(clientName string, serverAddr string) string {
+	return workspaceStatePath(".p4chimari_scancache_", clientName, serverAddr)
+}
+
+// loadScanCache loads the cache of a workspace (empty if there is none)
+func loadScanCache(clientName string, serverAddr string) *ScanCache {
+	cache := &ScanCache{path: getScanCachePath(clientName, serverAddr), Files: make(map[string]ScanCacheEntry)}
+
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		return cache
+	}
+
+	// A corrupt cache just means everything gets checked again
+	if err := json.Unmarshal(data, cache); err != nil || cache.Files == nil {
+		cache.Files = make(map[string]ScanCacheEntry)
+		cache.Reconciled, cache.Folders = nil, nil
+	}
+
+	return cache
+}
+
+// Len returns how many files the cache knows about
+func (c *ScanCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.Files) + len(c.Reconciled)
+}
+
+// lookup returns the cached entry for a file if its size and mtime still match
+func (c *ScanCache) lookup(path string, info os.FileInfo) (ScanCacheEntry, bool) {
+	if c == nil {
+		return ScanCacheEntry{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Files[path]
+	if !ok || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() {
+		return ScanCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store records the scan result of a file
+func (c *ScanCache) store(path string, info os.FileInfo, digest string, haveDigest string, status string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Files[path] = ScanCacheEntry{
+		Size:       info.Size(),
+		ModTime:    info.ModTime().UnixNano(),
+		Digest:     digest,
+		HaveDigest: haveDigest,
+		Status:     status,
+	}
+	c.dirty = true
+}
+
+// forget drops a file from the cache (e.g. it was deleted locally)
+func (c *ScanCache) forget(path string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Files[path]; ok {
+		delete(c.Files, path)
+		c.dirty = true
+	}
+}
+
+// Save writes the cache to disk if anything changed
+func (c *ScanCache) Save() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scan cache: %v", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write scan cache: %v", err)
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// invalidateScanCache deletes the cache of a workspace and returns how many
+// entries it held
+func invalidateScanCache(clientName string, serverAddr string) (int, error) {
+	cache := loadScanCache(clientName, serverAddr)
+
+	err := os.Remove(cache.path)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	return cache.Len(), nil
+}
+
+// ============================================================================
+// RECONCILE SCANS
+// ============================================================================
+
+// snapshotFolders stats every file under folders before a reconcile scan,
+// so a file changed while the scan runs is checked again next time
+func snapshotFolders(ctx context.Context, folders []string) (map[string]ScanCacheEntry, error) {
+	snapshot := make(map[string]ScanCacheEntry)
+
+	for _, folder := range folders {
+		err := filepath.WalkDir(folder, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return errCancelled
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			snapshot[filepath.Clean(path)] = ScanCacheEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return snapshot, nil
+}
+
+// coversFolders reports whether earlier full reconcile scans covered every
+// one of folders
+func (c *ScanCache) coversFolders(folders []string, caseSensitive bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, folder := range folders {
+		covered := false
+		for _, scanned := range c.Folders {
+			if pathHasPrefix(folder, scanned, caseSensitive) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileCandidates returns the files under folders a reconcile scan must
+// still look at: new files, files whose size or mtime changed, files missing
+// since the last scan and files the last scan reported as changed
+func (c *ScanCache) reconcileCandidates(snapshot map[string]ScanCacheEntry, folders []string, caseSensitive bool) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var candidates []string
+	for path, stamp := range snapshot {
+		entry, ok := c.Reconciled[path]
+		if !ok || entry.Status != "same" || entry.Size != stamp.Size || entry.ModTime != stamp.ModTime {
+			candidates = append(candidates, path)
+		}
+	}
+	for path := range c.Reconciled {
+		if _, exists := snapshot[path]; !exists && inFolders(path, folders, caseSensitive) {
+			candidates = append(candidates, path)
+		}
+	}
+	sort.Strings(candidates)
+
+	return candidates
+}
+
+// storeReconciled records a reconcile scan of paths: each file of the
+// snapshot is "same" unless the scan reported it, reported files without a
+// local file are kept as deletes, and anything else is forgotten
+func (c *ScanCache) storeReconciled(paths []string, snapshot map[string]ScanCacheEntry, reported []ModifiedFile, caseSensitive bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Reconciled == nil {
+		c.Reconciled = make(map[string]ScanCacheEntry)
+	}
+
+	actions := make(map[string]string)
+	for _, file := range reported {
+		actions[scanCacheKey(file.Path, caseSensitive)] = file.Action
+	}
+
+	for _, path := range paths {
+		action, wasReported := actions[scanCacheKey(path, caseSensitive)]
+		stamp, exists := snapshot[path]
+		switch {
+		case exists && wasReported:
+			stamp.Status = action
+			c.Reconciled[path] = stamp
+		case exists:
+			stamp.Status = "same"
+			c.Reconciled[path] = stamp
+		case wasReported:
+			c.Reconciled[path] = ScanCacheEntry{Size: -1, Status: action}
+		default:
+			delete(c.Reconciled, path)
+		}
+		delete(actions, scanCacheKey(path, caseSensitive))
+	}
+
+	// Deletes found by a full scan were not in the snapshot
+	for _, file := range reported {
+		if _, pending := actions[scanCacheKey(file.Path, caseSensitive)]; pending {
+			c.Reconciled[filepath.Clean(file.Path)] = ScanCacheEntry{Size: -1, Status: file.Action}
+		}
+	}
+	c.dirty = true
+}
+
+// storeFullReconcile replaces everything cached under folders with the
+// result of a full reconcile scan of them, and marks them covered
+func (c *ScanCache) storeFullReconcile(folders []string, snapshot map[string]ScanCacheEntry, reported []ModifiedFile, caseSensitive bool) {
+	c.mu.Lock()
+	for path := range c.Reconciled {
+		if inFolders(path, folders, caseSensitive) {
+			delete(c.Reconciled, path)
+		}
+	}
+	var kept []string
+	for _, scanned := range c.Folders {
+		if !inFolders(scanned, folders, caseSensitive) {
+			kept = append(kept, scanned)
+		}
+	}
+	for _, folder := range folders {
+		kept = append(kept, filepath.Clean(folder))
+	}
+	c.Folders = kept
+	c.mu.Unlock()
+
+	paths := make([]string, 0, len(snapshot))
+	for path := range snapshot {
+		paths = append(paths, path)
+	}
+	c.storeReconciled(paths, snapshot, reported, caseSensitive)
+}
+
+// inFolders reports whether path lies in one of folders
+func inFolders(path string, folders []string, caseSensitive bool) bool {
+	for _, folder := range folders {
+		if pathHasPrefix(path, folder, caseSensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanCacheKey normalises a local path for matching scan results to files
+func scanCacheKey(path string, caseSensitive bool) string {
+	path = filepath.Clean(path)
+	if !caseSensitive {
+		path = strings.ToLower(path)
+	}
+	return path
+}
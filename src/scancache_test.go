@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reconcileRecords formats "p4 -ztag reconcile -n" output for local files
+func reconcileRecords(root string, actions map[string]string) string {
+	var names []string
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output := ""
+	for _, name := range names {
+		output += fmt.Sprintf("... depotFile //depot/Game/%s\n... clientFile %s\n... action %s\n\n", name, filepath.Join(root, filepath.FromSlash(name)), actions[name])
+	}
+	return output
+}
+
+// scannedActions returns "action name" for each scanned file, relative to root
+func scannedActions(root string, files []ModifiedFile) []string {
+	var actions []string
+	for _, file := range files {
+		rel, _ := filepath.Rel(root, file.Path)
+		actions = append(actions, file.Action+" "+filepath.ToSlash(rel))
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+func TestReconcileScanUsesScanCache(t *testing.T) {
+	useTempHome(t)
+	root, fake := setupFakeWorkspace(t)
+	folder := filepath.Join(root, "Content")
+	writeTestFile(t, root, "Content/edited.txt", "edited")
+	writeTestFile(t, root, "Content/clean.txt", "clean")
+	writeTestFile(t, root, "Content/Maps/clean.umap", "map")
+
+	// First scan: the whole folder goes to the server
+	fake.On("-ztag reconcile -n "+folderSpec(folder), FakeResponse{Stdout: reconcileRecords(root, map[string]string{
+		"Content/edited.txt":  "edit",
+		"Content/removed.txt": "delete",
+	})})
+
+	files, err := getModifiedFilesFullScan(context.Background(), []string{folder}, false)
+	if err != nil {
+		t.Fatalf("first scan error = %v", err)
+	}
+	if got := scannedActions(root, files); fmt.Sprint(got) != "[delete Content/removed.txt edit Content/edited.txt]" {
+		t.Fatalf("first scan = %v", got)
+	}
+
+	tests := []struct {
+		name           string
+		change         func()
+		response       map[string]string
+		wantCandidates []string
+		wantFiles      []string
+	}{
+		{
+			name:           "unchanged files are not sent again",
+			change:         func() {},
+			response:       map[string]string{"Content/edited.txt": "edit", "Content/removed.txt": "delete"},
+			wantCandidates: []string{"Content/edited.txt", "Content/removed.txt"},
+			wantFiles:      []string{"delete Content/removed.txt", "edit Content/edited.txt"},
+		},
+		{
+			name: "changed and new files are sent",
+			change: func() {
+				writeTestFile(t, root, "Content/Maps/clean.umap", "map, now edited")
+				writeTestFile(t, root, "Content/new.txt", "new")
+			},
+			response:       map[string]string{"Content/edited.txt": "edit", "Content/removed.txt": "delete", "Content/Maps/clean.umap": "edit", "Content/new.txt": "add"},
+			wantCandidates: []string{"Content/Maps/clean.umap", "Content/edited.txt", "Content/new.txt", "Content/removed.txt"},
+			wantFiles:      []string{"add Content/new.txt", "delete Content/removed.txt", "edit Content/Maps/clean.umap", "edit Content/edited.txt"},
+		},
+		{
+			name: "a file deleted locally is sent",
+			change: func() {
+				os.Remove(filepath.Join(root, "Content", "clean.txt"))
+			},
+			response:       map[string]string{"Content/edited.txt": "edit", "Content/removed.txt": "delete", "Content/Maps/clean.umap": "edit", "Content/new.txt": "add", "Content/clean.txt": "delete"},
+			wantCandidates: []string{"Content/Maps/clean.umap", "Content/clean.txt", "Content/edited.txt", "Content/new.txt", "Content/removed.txt"},
+			wantFiles:      []string{"add Content/new.txt", "delete Content/clean.txt", "delete Content/removed.txt", "edit Content/Maps/clean.umap", "edit Content/edited.txt"},
+		},
+	}
+
+	// Each scan makes one batched call; queued responses are used in order
+	for _, tt := range tests {
+		fake.On("-ztag -x - reconcile -n", FakeResponse{Stdout: reconcileRecords(root, tt.response)})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change()
+			before := len(fake.Calls)
+
+			files, err := getModifiedFilesFullScan(context.Background(), []string{folder}, false)
+			if err != nil {
+				t.Fatalf("getModifiedFilesFullScan() error = %v", err)
+			}
+			if got := scannedActions(root, files); fmt.Sprint(got) != fmt.Sprint(tt.wantFiles) {
+				t.Errorf("files = %v, want %v", got, tt.wantFiles)
+			}
+
+			var sent []string
+			for _, call := range fake.Calls[before:] {
+				if strings.Join(call.Args, " ") == "-ztag -x - reconcile -n" {
+					for _, line := range strings.Fields(call.Stdin) {
+						rel, _ := filepath.Rel(root, line)
+						sent = append(sent, filepath.ToSlash(rel))
+					}
+				}
+				if strings.Join(call.Args, " ") == "-ztag reconcile -n "+folderSpec(folder) {
+					t.Error("reconciled the whole folder again")
+				}
+			}
+			if fmt.Sprint(sent) != fmt.Sprint(tt.wantCandidates) {
+				t.Errorf("sent %v to reconcile, want %v", sent, tt.wantCandidates)
+			}
+		})
+	}
+}
+
+func TestReconcileCandidatesSizeAndMtime(t *testing.T) {
+	now := time.Now().UnixNano()
+	cache := &ScanCache{
+		Reconciled: map[string]ScanCacheEntry{
+			"/ws/a":    {Size: 1, ModTime: now, Status: "same"},
+			"/ws/b":    {Size: 1, ModTime: now, Status: "same"},
+			"/ws/c":    {Size: 1, ModTime: now, Status: "same"},
+			"/ws/d":    {Size: 1, ModTime: now, Status: "edit"},
+			"/ws/e":    {Size: 1, ModTime: now, Status: "same"},
+			"/other/f": {Size: 1, ModTime: now, Status: "same"},
+		},
+	}
+	snapshot := map[string]ScanCacheEntry{
+		"/ws/a": {Size: 1, ModTime: now},
+		"/ws/b": {Size: 2, ModTime: now},
+		"/ws/c": {Size: 1, ModTime: now + 1},
+		"/ws/d": {Size: 1, ModTime: now},
+		"/ws/g": {Size: 1, ModTime: now},
+	}
+
+	got := cache.reconcileCandidates(snapshot, []string{"/ws"}, true)
+	want := []string{"/ws/b", "/ws/c", "/ws/d", "/ws/e", "/ws/g"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("reconcileCandidates() = %v, want %v", got, want)
+	}
+}
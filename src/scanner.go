@@ -2,9 +2,9 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -16,6 +16,14 @@ type ModifiedFile struct {
 	Action     string
 	HasChanges bool
 	IsOpened   bool
+	MovedFrom  string // local path a "move" entry was renamed from
+	HeaderOnly bool   // re-saved package differing only in volatile header fields
+	FileDetails
+
+	// Revisions submitted after the have revision, newest first (out-of-date files)
+	NewerRevisions []RevisionInfo
+	// Pending resolve (files needing resolve)
+	Resolve *ResolveInfo
 }
 
 // ScanResult contains the results of scanning for modified files
@@ -23,8 +31,19 @@ type ScanResult struct {
 	OpenedWithChanges    []ModifiedFile
 	OpenedWithoutChanges []ModifiedFile
 	NotOpenedButModified []ModifiedFile
+	OpenedOutOfDate      []ModifiedFile // Opened files behind head; also listed in the opened categories
+	OpenedNeedResolve    []ModifiedFile // Opened files with a pending resolve; also listed in the opened categories
 	TotalScanned         int
 	ScanDuration         time.Duration
+	Cancelled            bool         // Scan stopped early (Ctrl+C or timeout); results are partial
+	CancelReason         string       // Why the scan stopped early
+	Ignored              *IgnoreStats // Adds suppressed by P4IGNORE files and presets
+}
+
+// markCancelled records that the scan stopped early and why
+func (r *ScanResult) markCancelled(err error) {
+	r.Cancelled = true
+	r.CancelReason = err.Error()
 }
 
 // ============================================================================
@@ -32,7 +51,7 @@ type ScanResult struct {
 // ============================================================================
 
 // ScanForModifiedFilesScoped scans with custom selection and optional path scope
-func ScanForModifiedFilesScoped(folders []string, scopePath string, verbose bool, scanOpenedWithChanges bool, scanOpenedWithoutChanges bool, scanNotOpened bool) (*ScanResult, error) {
+func ScanForModifiedFilesScoped(ctx context.Context, folders []string, scopePath string, verbose bool, scanOpenedWithChanges bool, scanOpenedWithoutChanges bool, scanNotOpened bool, scanOutOfDate bool, scanNeedResolve bool) (*ScanResult, error) {
 	startTime := time.Now()
 	result := &ScanResult{}
 
@@ -46,11 +65,14 @@ func ScanForModifiedFilesScoped(folders []string, scopePath string, verbose bool
 		if verbose {
 			fmt.Println("→ Finding opened files with changes...")
 		}
-		openedWithChanges, err := getOpenedFilesWithChangesScoped(scopePath, verbose)
-		if err != nil {
+		openedWithChanges, err := getOpenedFilesWithChangesScoped(ctx, scopePath, verbose)
+		if err != nil && !isInterrupted(ctx, err) {
 			return nil, fmt.Errorf("failed to get opened files with changes: %v", err)
 		}
 		result.OpenedWithChanges = openedWithChanges
+		if err != nil {
+			result.markCancelled(err)
+		}
 		if verbose {
 			fmt.Printf("  ✓ Found %d file(s) opened with changes\n", len(openedWithChanges))
 		}
@@ -59,15 +81,18 @@ func ScanForModifiedFilesScoped(folders []string, scopePath string, verbose bool
 	}
 
 	// 2. Get files opened for edit without changes (p4 diff -sr)
-	if scanOpenedWithoutChanges {
+	if scanOpenedWithoutChanges && !result.Cancelled {
 		if verbose {
 			fmt.Println("→ Finding opened files without changes (hijacked)...")
 		}
-		openedWithoutChanges, err := getOpenedFilesWithoutChangesScoped(scopePath, verbose)
-		if err != nil {
+		openedWithoutChanges, err := getOpenedFilesWithoutChangesScoped(ctx, scopePath, verbose)
+		if err != nil && !isInterrupted(ctx, err) {
 			return nil, fmt.Errorf("failed to get hijacked files: %v", err)
 		}
 		result.OpenedWithoutChanges = openedWithoutChanges
+		if err != nil {
+			result.markCancelled(err)
+		}
 		if verbose {
 			fmt.Printf("  ✓ Found %d hijacked file(s)\n", len(openedWithoutChanges))
 		}
@@ -75,16 +100,43 @@ func ScanForModifiedFilesScoped(folders []string, scopePath string, verbose bool
 		result.OpenedWithoutChanges = []ModifiedFile{}
 	}
 
-	// 3. Get files modified but not opened (p4 reconcile -n)
-	if scanNotOpened {
+	// Re-saved .uasset/.umap files that only got a new GUID or saved hash are hijacked too
+	// (only when both categories are shown, so no file drops out of the results)
+	if scanOpenedWithChanges && scanOpenedWithoutChanges && !result.Cancelled {
+		if verbose {
+			fmt.Println("→ Comparing package headers of changed .uasset/.umap files...")
+		}
+		changed, headerOnly := splitHeaderOnlyPackages(ctx, result.OpenedWithChanges)
+		result.OpenedWithChanges = changed
+		result.OpenedWithoutChanges = append(result.OpenedWithoutChanges, headerOnly...)
+		if verbose {
+			fmt.Printf("  ✓ Found %d package(s) changed only in volatile header fields (hijacked)\n", len(headerOnly))
+		}
+	}
+
+	// 3. Get files modified but not opened (p4 reconcile -n, or local digests)
+	if scanNotOpened && !result.Cancelled {
 		if verbose {
 			fmt.Println("→ Finding modified files not yet opened...")
 		}
-		notOpenedButModified, err := getModifiedFilesNotOpened(folders, verbose)
-		if err != nil {
+		notOpenedButModified, ignored, err := getModifiedFilesNotOpened(ctx, folders, verbose)
+		if err != nil && !isInterrupted(ctx, err) {
 			return nil, fmt.Errorf("failed to get modified files: %v", err)
 		}
+
+		// Renamed assets show up as a delete plus an add; report them as moves
+		if err == nil {
+			before := len(notOpenedButModified)
+			notOpenedButModified = pairMovedFiles(ctx, notOpenedButModified)
+			if verbose && len(notOpenedButModified) < before {
+				fmt.Printf("  ✓ Paired %d delete/add(s) as moves\n", before-len(notOpenedButModified))
+			}
+		}
 		result.NotOpenedButModified = notOpenedButModified
+		result.Ignored = ignored
+		if err != nil {
+			result.markCancelled(err)
+		}
 		if verbose {
 			fmt.Printf("  ✓ Found %d modified file(s) not opened\n", len(notOpenedButModified))
 		}
@@ -92,12 +144,64 @@ func ScanForModifiedFilesScoped(folders []string, scopePath string, verbose bool
 		result.NotOpenedButModified = []ModifiedFile{}
 	}
 
+	// 4. Get opened files whose have revision is behind head (p4 fstat -Ro)
+	if scanOutOfDate && !result.Cancelled {
+		if verbose {
+			fmt.Println("→ Finding opened files that are out of date...")
+		}
+		openedOutOfDate, err := getOpenedFilesOutOfDate(ctx, scopePath, verbose)
+		if err != nil && !isInterrupted(ctx, err) {
+			return nil, fmt.Errorf("failed to get out-of-date files: %v", err)
+		}
+		result.OpenedOutOfDate = openedOutOfDate
+		if err != nil {
+			result.markCancelled(err)
+		}
+		if verbose {
+			fmt.Printf("  ✓ Found %d out-of-date file(s)\n", len(openedOutOfDate))
+		}
+	} else {
+		result.OpenedOutOfDate = []ModifiedFile{}
+	}
+
+	// 5. Get opened files with a pending resolve (p4 resolve -n)
+	if scanNeedResolve && !result.Cancelled {
+		if verbose {
+			fmt.Println("→ Finding opened files that need resolving...")
+		}
+		openedNeedResolve, err := getUnresolvedFiles(ctx, scopePath, verbose)
+		if err != nil && !isInterrupted(ctx, err) {
+			return nil, fmt.Errorf("failed to get unresolved files: %v", err)
+		}
+		result.OpenedNeedResolve = openedNeedResolve
+		if err != nil {
+			result.markCancelled(err)
+		}
+		if verbose {
+			fmt.Printf("  ✓ Found %d file(s) needing resolve\n", len(openedNeedResolve))
+		}
+	} else {
+		result.OpenedNeedResolve = []ModifiedFile{}
+	}
+
+	// Revisions, file types and changelists for the results screens
+	if !result.Cancelled {
+		if verbose {
+			fmt.Println("→ Reading file details (p4 fstat)...")
+		}
+		enrichModifiedFiles(ctx, result.OpenedWithChanges, result.OpenedWithoutChanges, result.NotOpenedButModified, result.OpenedOutOfDate, result.OpenedNeedResolve)
+	}
+
 	result.TotalScanned = len(result.OpenedWithChanges) + len(result.OpenedWithoutChanges) + len(result.NotOpenedButModified)
 	result.ScanDuration = time.Since(startTime)
 
 	if verbose {
 		fmt.Println("─────────────────────────────────────")
-		fmt.Printf("✓ Scan complete (took %.1fs)\n", result.ScanDuration.Seconds())
+		if result.Cancelled {
+			fmt.Printf("⚠ Scan CANCELLED after %.1fs (%s) - results are partial\n", result.ScanDuration.Seconds(), result.CancelReason)
+		} else {
+			fmt.Printf("✓ Scan complete (took %.1fs)\n", result.ScanDuration.Seconds())
+		}
 	}
 
 	return result, nil
@@ -108,8 +212,8 @@ func ScanForModifiedFilesScoped(folders []string, scopePath string, verbose bool
 // ============================================================================
 
 // ScanForModifiedFiles scans for all types of modified files (full scan)
-func ScanForModifiedFiles(folders []string, verbose bool) (*ScanResult, error) {
-	return ScanForModifiedFilesScoped(folders, "", verbose, true, true, true)
+func ScanForModifiedFiles(ctx context.Context, folders []string, verbose bool) (*ScanResult, error) {
+	return ScanForModifiedFilesScoped(ctx, folders, "", verbose, true, true, true, true, true)
 }
 
 // ============================================================================
@@ -117,17 +221,12 @@ func ScanForModifiedFiles(folders []string, verbose bool) (*ScanResult, error) {
 // ============================================================================
 
 // getOpenedFilesWithChangesScoped returns files that are opened for edit and have actual changes in a specific scope
-func getOpenedFilesWithChangesScoped(scopePath string, verbose bool) ([]ModifiedFile, error) {
-	var cmd *exec.Cmd
-	if scopePath == "" {
-		cmd = exec.Command("p4", "diff", "-se")
-		if verbose {
-			fmt.Println("  Executing: p4 diff -se")
-		}
-	} else {
-		cmd = exec.Command("p4", "diff", "-se", scopePath)
-		if verbose {
-			fmt.Printf("  Executing: p4 diff -se %s\n", scopePath)
+func getOpenedFilesWithChangesScoped(ctx context.Context, scopePath string, verbose bool) ([]ModifiedFile, error) {
+	if verbose {
+		if scopePath == "" {
+			fmt.Println("  Executing: p4 -ztag diff -se")
+		} else {
+			fmt.Printf("  Executing: p4 -ztag diff -se %s\n", scopePath)
 		}
 	}
 
@@ -137,49 +236,39 @@ func getOpenedFilesWithChangesScoped(scopePath string, verbose bool) ([]Modified
 		go showSpinner(done, "  ")
 	}
 
-	output, _ := cmd.Output()
-	outputStr := string(output)
+	records, err := p4DiffStatus(ctx, "e", scopePath)
+	resolveLocalPaths(ctx, records)
 
 	if verbose {
 		done <- true
 		time.Sleep(50 * time.Millisecond) // Let spinner clean up
 	}
 
-	if len(outputStr) == 0 {
-		return []ModifiedFile{}, nil
-	}
-
-	var files []ModifiedFile
-	lines := strings.Split(outputStr, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
+	files := []ModifiedFile{}
+	for _, record := range records {
 		files = append(files, ModifiedFile{
-			Path:       line,
-			Action:     "edit",
-			HasChanges: true,
-			IsOpened:   true,
+			Path:        recordLocalPath(record),
+			Action:      "edit",
+			HasChanges:  true,
+			IsOpened:    true,
+			FileDetails: detailsFromRecord(record),
 		})
 	}
 
+	// Only an interrupted run is an error; p4 diff exits non-zero when files differ
+	if isInterrupted(ctx, err) {
+		return files, err
+	}
 	return files, nil
 }
 
 // getOpenedFilesWithoutChangesScoped returns files that are opened but have no changes (hijacked) in a specific scope
-func getOpenedFilesWithoutChangesScoped(scopePath string, verbose bool) ([]ModifiedFile, error) {
-	var cmd *exec.Cmd
-	if scopePath == "" {
-		cmd = exec.Command("p4", "diff", "-sr")
-		if verbose {
-			fmt.Println("  Executing: p4 diff -sr")
-		}
-	} else {
-		cmd = exec.Command("p4", "diff", "-sr", scopePath)
-		if verbose {
-			fmt.Printf("  Executing: p4 diff -sr %s\n", scopePath)
+func getOpenedFilesWithoutChangesScoped(ctx context.Context, scopePath string, verbose bool) ([]ModifiedFile, error) {
+	if verbose {
+		if scopePath == "" {
+			fmt.Println("  Executing: p4 -ztag diff -sr")
+		} else {
+			fmt.Printf("  Executing: p4 -ztag diff -sr %s\n", scopePath)
 		}
 	}
 
@@ -189,135 +278,178 @@ func getOpenedFilesWithoutChangesScoped(scopePath string, verbose bool) ([]Modif
 		go showSpinner(done, "  ")
 	}
 
-	output, _ := cmd.Output()
-	outputStr := string(output)
+	records, err := p4DiffStatus(ctx, "r", scopePath)
+	resolveLocalPaths(ctx, records)
 
 	if verbose {
 		done <- true
 		time.Sleep(50 * time.Millisecond) // Let spinner clean up
 	}
 
-	if len(outputStr) == 0 {
-		return []ModifiedFile{}, nil
-	}
-
-	var files []ModifiedFile
-	lines := strings.Split(outputStr, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
+	files := []ModifiedFile{}
+	for _, record := range records {
 		files = append(files, ModifiedFile{
-			Path:       line,
-			Action:     "edit",
-			HasChanges: false,
-			IsOpened:   true,
+			Path:        recordLocalPath(record),
+			Action:      "edit",
+			HasChanges:  false,
+			IsOpened:    true,
+			FileDetails: detailsFromRecord(record),
 		})
 	}
 
+	// Only an interrupted run is an error; p4 diff exits non-zero when files differ
+	if isInterrupted(ctx, err) {
+		return files, err
+	}
 	return files, nil
 }
 
-// getModifiedFilesNotOpened returns files that have been modified but not opened for edit
-func getModifiedFilesNotOpened(folders []string, verbose bool) ([]ModifiedFile, error) {
+// getModifiedFilesNotOpened returns files that have been modified but not
+// opened for edit, leaving out adds matched by ignore rules
+func getModifiedFilesNotOpened(ctx context.Context, folders []string, verbose bool) ([]ModifiedFile, *IgnoreStats, error) {
 	if len(folders) == 0 {
 		folders = []string{"."}
 	}
 
-	var allFiles []ModifiedFile
+	// The watcher and its touched paths work with absolute paths
+	absFolders := make([]string, len(folders))
+	for i, folder := range folders {
+		absFolders[i] = folder
+		if absFolder, err := filepath.Abs(folder); err == nil {
+			absFolders[i] = absFolder
+		}
+	}
 
-	for _, folder := range folders {
+	caseSensitive := true
+	if view, err := getClientView(ctx); err == nil {
+		caseSensitive = view.CaseSensitive
+	}
+
+	// With a primed watcher only the touched paths need checking
+	scanStart := time.Now()
+	if paths, ok := activeWatcher.candidates(absFolders, caseSensitive); ok {
 		if verbose {
-			fmt.Printf("  Scanning folder: %s\n", folder)
+			fmt.Printf("  Using workspace watcher: %d touched path(s) in scope\n", len(paths))
 		}
+		files, err := getModifiedFilesFromWatcher(ctx, paths, verbose)
 
-		files, err := scanFolderForUnopened(folder, verbose)
-		if err != nil {
-			return nil, err
+		// Only the files still dirty need checking again next time
+		if err == nil {
+			dirty := make([]string, len(files))
+			for i, file := range files {
+				dirty[i] = file.Path
+			}
+			activeWatcher.settle(absFolders, scanStart, dirty, caseSensitive)
 		}
-		allFiles = append(allFiles, files...)
+		files, ignored := filterIgnoredAdds(ctx, files)
+		return files, ignored, err
 	}
-
-	return allFiles, nil
-}
-
-// scanFolderForUnopened scans a folder for modified but unopened files
-func scanFolderForUnopened(folder string, verbose bool) ([]ModifiedFile, error) {
-	cmd := exec.Command("p4", "reconcile", "-n", folder+"/...")
-
-	if verbose {
-		fmt.Printf("  Executing: p4 reconcile -n %s/...\n", folder)
+	if activeWatcher != nil && verbose {
+		fmt.Println("  Workspace watcher has no complete picture of these folders yet - running a full scan")
 	}
 
-	// Show progress spinner
-	done := make(chan bool)
-	if verbose {
-		go showSpinner(done, "  ")
+	allFiles, err := getModifiedFilesFullScan(ctx, folders, verbose)
+
+	// A complete full scan lets the watcher take over for these folders
+	if err == nil {
+		dirty := make([]string, len(allFiles))
+		for i, file := range allFiles {
+			dirty[i] = file.Path
+		}
+		activeWatcher.prime(absFolders, scanStart, dirty)
 	}
 
-	output, _ := cmd.CombinedOutput()
-	outputStr := string(output)
+	allFiles, ignored := filterIgnoredAdds(ctx, allFiles)
+	return allFiles, ignored, err
+}
 
-	if verbose {
-		done <- true
-		time.Sleep(50 * time.Millisecond) // Let spinner clean up
+// getModifiedFilesFullScan checks every file in folders with reconcile or
+// local digests, depending on detectMode
+func getModifiedFilesFullScan(ctx context.Context, folders []string, verbose bool) ([]ModifiedFile, error) {
+	if detectMode == detectModeDigest {
+		return getModifiedFilesByDigest(ctx, folders, verbose)
 	}
 
-	if len(outputStr) == 0 {
-		return []ModifiedFile{}, nil
+	view, err := getClientView(ctx)
+	if err != nil {
+		return reconcileFoldersFully(ctx, folders, verbose)
 	}
 
-	var files []ModifiedFile
-	lines := strings.Split(outputStr, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+	// Files whose size and mtime are unchanged since the last reconcile scan
+	// are not sent to the server again (see ScanCache.reconcileCandidates).
+	// The cache works with absolute paths, like the paths p4 reports.
+	absFolders := make([]string, len(folders))
+	for i, folder := range folders {
+		absFolders[i] = folder
+		if absFolder, err := filepath.Abs(folder); err == nil {
+			absFolders[i] = absFolder
+		}
+	}
+	cache := loadScanCache(view.ClientName, view.ServerAddr)
+	snapshot, err := snapshotFolders(ctx, absFolders)
+	if err != nil {
+		if isInterrupted(ctx, err) {
+			return []ModifiedFile{}, err
+		}
+		if verbose {
+			fmt.Printf("  ⚠ Scan cache not used, failed to list local files: %v\n", err)
 		}
+		return reconcileFoldersFully(ctx, folders, verbose)
+	}
 
-		// Parse p4 reconcile -n output
-		if strings.Contains(line, "//") && strings.Contains(line, " - reconcile to ") {
-			depotPath := ""
-			if strings.Contains(line, "#") {
-				parts := strings.Split(line, "#")
-				depotPath = strings.TrimSpace(parts[0])
-			} else {
-				parts := strings.Split(line, " - ")
-				if len(parts) >= 1 {
-					depotPath = strings.TrimSpace(parts[0])
-				}
-			}
+	var allFiles []ModifiedFile
+	if cache.coversFolders(absFolders, view.CaseSensitive) {
+		candidates := cache.reconcileCandidates(snapshot, absFolders, view.CaseSensitive)
+		if verbose {
+			fmt.Printf("  Scan cache: %d of %d local file(s) unchanged since the last scan, reconciling %d\n", len(snapshot)-len(candidates), len(snapshot), len(candidates))
+		}
+		allFiles, err = reconcilePaths(ctx, candidates, "changed", verbose)
+		if err == nil {
+			cache.storeReconciled(candidates, snapshot, allFiles, view.CaseSensitive)
+		}
+	} else {
+		allFiles, err = reconcileFoldersFully(ctx, folders, verbose)
+		if err == nil {
+			cache.storeFullReconcile(absFolders, snapshot, allFiles, view.CaseSensitive)
+		}
+	}
 
-			if depotPath == "" {
-				continue
-			}
+	if saveErr := cache.Save(); saveErr != nil && verbose {
+		fmt.Printf("  ⚠ %v\n", saveErr)
+	}
+	return allFiles, err
+}
 
-			// Convert depot path to local path
-			localPath, err := depotToLocalPath(depotPath)
-			if err != nil {
-				localPath = depotPath
-			}
+// reconcileFoldersFully runs "p4 reconcile -n" over every file in folders
+func reconcileFoldersFully(ctx context.Context, folders []string, verbose bool) ([]ModifiedFile, error) {
+	// Folders are reconciled in parallel; on interruption the files found so
+	// far are still returned along with the error
+	records, err := reconcileFolders(ctx, folders, verbose)
+
+	allFiles := []ModifiedFile{}
+	for _, record := range records {
+		allFiles = append(allFiles, ModifiedFile{
+			Path:        recordLocalPath(record),
+			Action:      record.Action,
+			HasChanges:  true,
+			IsOpened:    false,
+			FileDetails: detailsFromRecord(record),
+		})
+	}
 
-			// Determine action
-			action := "edit"
-			if strings.Contains(line, "add") {
-				action = "add"
-			} else if strings.Contains(line, "delete") {
-				action = "delete"
-			}
+	return allFiles, err
+}
 
-			files = append(files, ModifiedFile{
-				Path:       localPath,
-				Action:     action,
-				HasChanges: true,
-				IsOpened:   false,
-			})
-		}
+// recordLocalPath returns the local path of a record, falling back to the
+// depot path when it could not be resolved (see resolveLocalPaths)
+func recordLocalPath(record FileRecord) string {
+	if record.LocalPath != "" {
+		return filepath.Clean(record.LocalPath)
 	}
-
-	return files, nil
+	if localPath, ok := cachedLocalPath(record.DepotFile); ok {
+		return localPath
+	}
+	return record.DepotFile
 }
 
 // ============================================================================
@@ -334,49 +466,56 @@ func PrintScanResults(result *ScanResult) {
 	fmt.Printf("  ✓ Opened with changes:          %d\n", len(result.OpenedWithChanges))
 	fmt.Printf("  ⚠ Opened without changes:        %d (hijacked)\n", len(result.OpenedWithoutChanges))
 	fmt.Printf("  📝 Modified but not opened:      %d\n", len(result.NotOpenedButModified))
+	if moves := countMoves(result.NotOpenedButModified); moves > 0 {
+		fmt.Printf("  🔀 Renamed/moved (not opened):   %d\n", moves)
+	}
+	fmt.Printf("  ⏳ Opened but out of date:       %d (behind head)\n", len(result.OpenedOutOfDate))
+	fmt.Printf("  🔀 Opened, needs resolve:        %d\n", len(result.OpenedNeedResolve))
+	if result.Ignored != nil && result.Ignored.Total > 0 {
+		fmt.Printf("  🙈 Suppressed by ignore rules:   %d\n", result.Ignored.Total)
+	}
 	fmt.Printf("  ⏱ Scan duration:                 %.1fs\n", result.ScanDuration.Seconds())
+	if result.Cancelled {
+		fmt.Printf("  ⛔ CANCELLED - partial results:  %s\n", result.CancelReason)
+	}
 	fmt.Println()
 
 	if len(result.OpenedWithChanges) > 0 {
 		fmt.Println("─────────────────────────────────────")
 		fmt.Printf("✓ Files Opened with REAL Changes (%d):\n", len(result.OpenedWithChanges))
-		for i, file := range result.OpenedWithChanges {
-			if i < 15 {
-				fmt.Printf("  [%s] %s\n", strings.ToUpper(file.Action), file.Path)
-			}
-		}
-		if len(result.OpenedWithChanges) > 15 {
-			fmt.Printf("  ... and %d more\n", len(result.OpenedWithChanges)-15)
-		}
+		printFileTable(result.OpenedWithChanges, 15)
 	}
 
 	if len(result.OpenedWithoutChanges) > 0 {
 		fmt.Println("\n─────────────────────────────────────")
 		fmt.Printf("⚠ Hijacked Files (Opened but NO changes) (%d):\n", len(result.OpenedWithoutChanges))
-		for i, file := range result.OpenedWithoutChanges {
-			if i < 15 {
-				fmt.Printf("  %s\n", file.Path)
-			}
-		}
-		if len(result.OpenedWithoutChanges) > 15 {
-			fmt.Printf("  ... and %d more\n", len(result.OpenedWithoutChanges)-15)
-		}
+		printFileTable(result.OpenedWithoutChanges, 15)
 	}
 
 	if len(result.NotOpenedButModified) > 0 {
 		fmt.Println("\n─────────────────────────────────────")
 		fmt.Printf("📝 Modified Files NOT Opened (%d):\n", len(result.NotOpenedButModified))
-		for i, file := range result.NotOpenedButModified {
-			if i < 15 {
-				fmt.Printf("  [%s] %s\n", strings.ToUpper(file.Action), file.Path)
-			}
-		}
-		if len(result.NotOpenedButModified) > 15 {
-			fmt.Printf("  ... and %d more\n", len(result.NotOpenedButModified)-15)
-		}
+		printFileTable(result.NotOpenedButModified, 15)
 	}
 
-	if result.TotalScanned == 0 {
+	if len(result.OpenedOutOfDate) > 0 {
+		fmt.Println("\n─────────────────────────────────────")
+		fmt.Printf("⏳ Opened Files OUT OF DATE - newer revisions submitted (%d):\n", len(result.OpenedOutOfDate))
+		printOutOfDateTable(result.OpenedOutOfDate, 15)
+	}
+
+	if len(result.OpenedNeedResolve) > 0 {
+		fmt.Println("\n─────────────────────────────────────")
+		fmt.Printf("🔀 Opened Files NEEDING RESOLVE (%d):\n", len(result.OpenedNeedResolve))
+		printResolveTable(result.OpenedNeedResolve, 15)
+	}
+
+	if result.Ignored != nil && result.Ignored.Total > 0 {
+		fmt.Println("\n─────────────────────────────────────")
+		result.Ignored.Print("")
+	}
+
+	if result.TotalScanned == 0 && len(result.OpenedOutOfDate) == 0 && len(result.OpenedNeedResolve) == 0 && !result.Cancelled {
 		fmt.Println("✨ All clean! No modifications found in your workspace.")
 	}
 
@@ -388,7 +527,7 @@ func PrintScanResults(result *ScanResult) {
 // ============================================================================
 
 // ShowModifiedFilesAndRevert shows all modified files and allows selective force sync
-func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
+func ShowModifiedFilesAndRevert(ctx context.Context, p4Info *P4Info, reader *bufio.Reader) {
 	// First, ask for directory/scope to limit the scan
 	fmt.Println("\n─────────────────────────────────────")
 	fmt.Println("SELECT SCAN SCOPE")
@@ -455,10 +594,12 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 	fmt.Println("  1. Opened files with changes")
 	fmt.Println("  2. Opened files without changes (hijacked)")
 	fmt.Println("  3. Modified files not opened yet")
+	fmt.Println("  4. Opened files behind head (out of date)")
+	fmt.Println("  5. Opened files needing resolve")
 	fmt.Println()
 	fmt.Println("Enter selection (comma-separated, e.g., '1,2' or '1,2,3'):")
 	fmt.Println("  Quick:  1,2")
-	fmt.Println("  Full:   1,2,3")
+	fmt.Println("  Full:   1,2,3,4,5")
 	fmt.Print("\nYour choice: ")
 
 	scanChoice, _ := reader.ReadString('\n')
@@ -474,6 +615,8 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 	scanOpenedWithChanges := false
 	scanOpenedWithoutChanges := false
 	scanNotOpened := false
+	scanOutOfDate := false
+	scanNeedResolve := false
 
 	for _, sel := range selections {
 		sel = strings.TrimSpace(sel)
@@ -484,13 +627,17 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 			scanOpenedWithoutChanges = true
 		case "3":
 			scanNotOpened = true
+		case "4":
+			scanOutOfDate = true
+		case "5":
+			scanNeedResolve = true
 		default:
 			fmt.Printf("Invalid selection: %s\n", sel)
 			return
 		}
 	}
 
-	if !scanOpenedWithChanges && !scanOpenedWithoutChanges && !scanNotOpened {
+	if !scanOpenedWithChanges && !scanOpenedWithoutChanges && !scanNotOpened && !scanOutOfDate && !scanNeedResolve {
 		fmt.Println("No valid selections made.")
 		return
 	}
@@ -521,7 +668,7 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 			folders = []string{projectPath}
 			fmt.Printf("\n📂 Scanning: Project folder (%s)\n", projectPath)
 		case "3":
-			currentDir, _ := depotToLocalPath(".")
+			currentDir, _ := depotToLocalPath(ctx, ".")
 			if currentDir == "" {
 				currentDir = "."
 			}
@@ -553,6 +700,32 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 			fmt.Println("Invalid choice.")
 			return
 		}
+
+		// Ask how to detect them
+		fmt.Println("\n─────────────────────────────────────")
+		fmt.Println("SELECT DETECTION METHOD")
+		fmt.Println("─────────────────────────────────────")
+		fmt.Println("  1. p4 reconcile -n (server compares files, finds new files; repeat scans only send files whose size or mtime changed)")
+		fmt.Println("  2. Local digest compare (fstat -Ol + local MD5; repeat scans only hash files whose size or mtime changed)")
+		defaultMethod := "1"
+		if detectMode == detectModeDigest {
+			defaultMethod = "2"
+		}
+		fmt.Printf("\nEnter choice (1-2, Enter = %s): ", defaultMethod)
+
+		method, _ := reader.ReadString('\n')
+		method = strings.TrimSpace(method)
+
+		switch method {
+		case "":
+		case "1":
+			detectMode = detectModeReconcile
+		case "2":
+			detectMode = detectModeDigest
+		default:
+			fmt.Println("Invalid choice.")
+			return
+		}
 	} else {
 		// Not scanning unopened files - no directory selection needed
 		folders = []string{} // Empty means skip unopened files scan
@@ -560,7 +733,7 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 
 	// Show what we're scanning
 	fmt.Println()
-	if scanOpenedWithChanges && scanOpenedWithoutChanges && scanNotOpened {
+	if scanOpenedWithChanges && scanOpenedWithoutChanges && scanNotOpened && scanOutOfDate && scanNeedResolve {
 		fmt.Println("🔍 Running FULL scan (all files)...")
 	} else if scanOpenedWithChanges && scanOpenedWithoutChanges {
 		fmt.Println("🚀 Running QUICK scan (opened files only)...")
@@ -576,11 +749,17 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 		if scanNotOpened {
 			parts = append(parts, "unopened modified files")
 		}
+		if scanOutOfDate {
+			parts = append(parts, "out-of-date files")
+		}
+		if scanNeedResolve {
+			parts = append(parts, "files needing resolve")
+		}
 		fmt.Println(strings.Join(parts, ", ") + "...")
 	}
 
 	// Scan for modified files with path scope
-	result, err := ScanForModifiedFilesScoped(folders, scanPath, true, scanOpenedWithChanges, scanOpenedWithoutChanges, scanNotOpened)
+	result, err := ScanForModifiedFilesScoped(ctx, folders, scanPath, true, scanOpenedWithChanges, scanOpenedWithoutChanges, scanNotOpened, scanOutOfDate, scanNeedResolve)
 	if err != nil {
 		fmt.Printf("Error scanning: %v\n", err)
 		return
@@ -589,6 +768,17 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 	// Print results
 	PrintScanResults(result)
 
+	if result.Cancelled {
+		return
+	}
+
+	// Out-of-date and unresolved files are the usual reasons a submit fails;
+	// deal with them first
+	synced := syncOutOfDateFiles(ctx, result.OpenedOutOfDate, reader)
+	if synced || len(result.OpenedNeedResolve) > 0 {
+		offerResolveScreen(ctx, reader)
+	}
+
 	if result.TotalScanned == 0 {
 		return
 	}
@@ -618,7 +808,7 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 		if file.IsOpened {
 			status = "OPENED"
 		}
-		fmt.Printf("  %d. [%s] [%s] %s\n", i+1, status, strings.ToUpper(file.Action), file.Path)
+		fmt.Printf("  %d. [%s] [%s] %s\n", i+1, status, strings.ToUpper(file.Action), movePathLabel(file.Path, file.MovedFrom))
 	}
 
 	fmt.Println()
@@ -627,6 +817,7 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 	fmt.Println("  - Multiple: 1,3,5")
 	fmt.Println("  - Range: 1-5")
 	fmt.Println("  - All: all")
+	fmt.Println("  - Hide junk adds with ignore rules: ignore")
 	fmt.Println("  - Cancel: cancel or press Enter")
 	fmt.Print("\nYour choice: ")
 
@@ -638,6 +829,17 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 		return
 	}
 
+	if input == "ignore" {
+		var addPaths []string
+		for _, file := range result.NotOpenedButModified {
+			if file.Action == "add" {
+				addPaths = append(addPaths, file.Path)
+			}
+		}
+		editP4Ignore(ctx, addPaths, reader)
+		return
+	}
+
 	// Parse selection
 	selectedFiles := parseFileSelection(input, allModifiedFiles)
 
@@ -653,7 +855,7 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 	fmt.Println("Files to be force synced:")
 	for i, file := range selectedFiles {
 		if i < 10 {
-			fmt.Printf("  • %s\n", file.Path)
+			fmt.Printf("  • %s\n", movePathLabel(file.Path, file.MovedFrom))
 		}
 	}
 	if len(selectedFiles) > 10 {
@@ -671,21 +873,23 @@ func ShowModifiedFilesAndRevert(p4Info *P4Info, reader *bufio.Reader) {
 
 	// Force sync the selected files
 	fmt.Println("\nForce syncing files...")
+	var paths []string
+	var moves []MovedPath
 	for _, file := range selectedFiles {
-		fmt.Printf("  p4 sync -f %s\n", file.Path)
-		cmd := exec.Command("p4", "sync", "-f", file.Path)
-		output, err := cmd.CombinedOutput()
-
-		if err != nil {
-			fmt.Printf("    ✗ Error: %v\n", err)
-			if len(output) > 0 {
-				fmt.Printf("    %s\n", string(output))
-			}
-		} else {
-			fmt.Printf("    ✓ %s\n", strings.TrimSpace(string(output)))
+		if file.MovedFrom != "" {
+			moves = append(moves, MovedPath{From: file.MovedFrom, To: file.Path})
+			continue
 		}
+		paths = append(paths, file.Path)
 	}
 
+	syncResult := restoreFromDepot(ctx, paths, moves)
+	printBatchSummary(syncResult)
+
+	if len(syncResult.Failed()) > 0 {
+		fmt.Println("\n⚠ Done, but some files could not be force synced (see above).")
+		return
+	}
 	fmt.Println("\n✓ Done! Files have been force synced from P4.")
 }
 
@@ -702,11 +906,11 @@ func showSpinner(done chan bool, prefix string) {
 		select {
 		case <-done:
 			elapsed := time.Since(startTime).Seconds()
-			fmt.Printf("\r%s✓ Complete (%.1fs)       \n", prefix, elapsed)
+			fmt.Printf("\r%s✓ Complete (%.1fs)                          \n", prefix, elapsed)
 			return
 		default:
 			elapsed := time.Since(startTime).Seconds()
-			fmt.Printf("\r%s%s Scanning... (%.0fs)", prefix, spinner[i%len(spinner)], elapsed)
+			fmt.Printf("\r%s%s Scanning... (%.0fs) [Ctrl+C to cancel]", prefix, spinner[i%len(spinner)], elapsed)
 			i++
 			time.Sleep(100 * time.Millisecond)
 		}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// diffRecords formats "p4 -ztag diff" output for depot files under //depot/Game
+func diffRecords(root string, names ...string) string {
+	output := ""
+	for _, name := range names {
+		output += fmt.Sprintf("... depotFile //depot/Game/%s\n... clientFile %s\n\n", name, filepath.Join(root, filepath.FromSlash(name)))
+	}
+	return output
+}
+
+// scannedDepotFiles returns the depot paths of scanned files
+func scannedDepotFiles(files []ModifiedFile) []string {
+	var paths []string
+	for _, file := range files {
+		label := file.DepotPath
+		if file.HeaderOnly {
+			label += " (header only)"
+		}
+		paths = append(paths, label)
+	}
+	return paths
+}
+
+func TestScanForModifiedFilesScopedOpenedFiles(t *testing.T) {
+	tests := []struct {
+		name                 string
+		withChanges          bool
+		withoutChanges       bool
+		wantWithChanges      []string
+		wantWithoutChanges   []string
+		wantHeaderComparison bool
+	}{
+		{
+			name:                 "both categories move header-only packages to hijacked",
+			withChanges:          true,
+			withoutChanges:       true,
+			wantWithChanges:      []string{"//depot/Game/Source/Hero.cpp"},
+			wantWithoutChanges:   []string{"//depot/Game/Config/Game.ini", "//depot/Game/Content/Hero.uasset (header only)"},
+			wantHeaderComparison: true,
+		},
+		{
+			name:            "changed files only keeps header-only packages",
+			withChanges:     true,
+			wantWithChanges: []string{"//depot/Game/Content/Hero.uasset", "//depot/Game/Source/Hero.cpp"},
+		},
+		{
+			name:               "unchanged files only",
+			withoutChanges:     true,
+			wantWithoutChanges: []string{"//depot/Game/Config/Game.ini"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, fake := setupFakeWorkspace(t)
+			fake.On("-ztag diff -se", FakeResponse{Stdout: diffRecords(root, "Content/Hero.uasset", "Source/Hero.cpp")})
+			fake.On("-ztag diff -sr", FakeResponse{Stdout: diffRecords(root, "Config/Game.ini")})
+			scriptHeaderOnlyPackage(t, fake, root, "Content/Hero.uasset")
+
+			result, err := ScanForModifiedFilesScoped(context.Background(), nil, "", false, tt.withChanges, tt.withoutChanges, false, false, false)
+			if err != nil {
+				t.Fatalf("ScanForModifiedFilesScoped() error = %v", err)
+			}
+
+			if got := scannedDepotFiles(result.OpenedWithChanges); fmt.Sprint(got) != fmt.Sprint(tt.wantWithChanges) {
+				t.Errorf("OpenedWithChanges = %v, want %v", got, tt.wantWithChanges)
+			}
+			if got := scannedDepotFiles(result.OpenedWithoutChanges); fmt.Sprint(got) != fmt.Sprint(tt.wantWithoutChanges) {
+				t.Errorf("OpenedWithoutChanges = %v, want %v", got, tt.wantWithoutChanges)
+			}
+			compared := fake.CallCount("-ztag -x - fstat -Ol -T depotFile,clientFile,haveRev,fileSize") > 0
+			if compared != tt.wantHeaderComparison {
+				t.Errorf("package headers compared = %v, want %v", compared, tt.wantHeaderComparison)
+			}
+		})
+	}
+}
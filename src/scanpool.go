@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultScanConcurrency is how many reconcile previews run at once by default
+const defaultScanConcurrency = 4
+
+// scanConcurrency is the worker pool size used for multi-folder scans
+var scanConcurrency = defaultScanConcurrency
+
+// FolderScan is the outcome of a reconcile preview of one folder or shard
+type FolderScan struct {
+	Folder   string
+	Spec     string
+	Records  []FileRecord
+	Duration time.Duration
+	Err      error
+}
+
+// scanShardsParallel runs "p4 reconcile -n" for each shard on a bounded
+// worker pool. onDone is called once per shard, in completion order and
+// never concurrently, so it can print progress. Results keep input order.
+func scanShardsParallel(ctx context.Context, shards []scanShard, concurrency int, onDone func(finished int, total int, scan FolderScan)) []FolderScan {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	scans := make([]FolderScan, len(shards))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var progressMu sync.Mutex
+	finished := 0
+
+	for w := 0; w < concurrency && w < len(shards); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				scans[index] = reconcileShard(ctx, shards[index])
+
+				progressMu.Lock()
+				finished++
+				if onDone != nil {
+					onDone(finished, len(shards), scans[index])
+				}
+				progressMu.Unlock()
+			}
+		}()
+	}
+
+	for index := range shards {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	return scans
+}
+
+// reconcileShard previews a reconcile of one shard and resolves local paths
+func reconcileShard(ctx context.Context, shard scanShard) FolderScan {
+	startTime := time.Now()
+	scan := FolderScan{Folder: shard.Folder, Spec: shard.Spec}
+
+	if ctx.Err() != nil {
+		scan.Err = errCancelled
+		return scan
+	}
+
+	records, err := p4ReconcilePreview(ctx, shard.Spec)
+	for _, record := range records {
+		// Anything without a depot path or action is an informational message
+		if record.DepotFile != "" && record.Action != "" {
+			scan.Records = append(scan.Records, record)
+		}
+	}
+	resolveLocalPaths(ctx, scan.Records)
+
+	// reconcile -n exits non-zero when there is nothing to do; any other
+	// failure must not pass for a clean folder. The records gathered so far
+	// are kept.
+	if err != nil && !isNoMatch(err) {
+		scan.Err = err
+	}
+	scan.Duration = time.Since(startTime)
+
+	return scan
+}
+
+// mergeFolderScans combines folder results, dropping files reported by more
+// than one (overlapping) folder, and returns the first error. An interruption
+// is returned as is; a failed shard is named in the error.
+func mergeFolderScans(scans []FolderScan) ([]FileRecord, error) {
+	var merged []FileRecord
+	var firstErr error
+	seen := make(map[string]bool)
+
+	for _, scan := range scans {
+		if scan.Err != nil && firstErr == nil {
+			firstErr = scan.Err
+			if !errors.Is(scan.Err, errCancelled) && !errors.Is(scan.Err, errTimedOut) {
+				firstErr = fmt.Errorf("reconcile of %s failed: %w", scan.Spec, scan.Err)
+			}
+		}
+		for _, record := range scan.Records {
+			if seen[record.DepotFile] {
+				continue
+			}
+			seen[record.DepotFile] = true
+			merged = append(merged, record)
+		}
+	}
+
+	return merged, firstErr
+}
+
+// reconcileFolders scans all folders in parallel and returns the merged
+// records with local paths resolved. Large folders are split into shards (see
+// planShards). With verbose set, each shard's files are printed as soon as it
+// finishes.
+func reconcileFolders(ctx context.Context, folders []string, verbose bool) ([]FileRecord, error) {
+	clientRoot := ""
+	if view, err := getClientView(ctx); err == nil {
+		clientRoot = view.Root
+	}
+
+	timings := loadShardTimings()
+	shards := planShards(ctx, folders, clientRoot, timings)
+
+	if verbose {
+		fmt.Printf("  Executing: p4 -ztag reconcile -n on %d folder(s) as %d shard(s), %d at a time [Ctrl+C to cancel]\n", len(folders), len(shards), scanConcurrency)
+	}
+
+	scans := scanShardsParallel(ctx, shards, scanConcurrency, func(finished int, total int, scan FolderScan) {
+		if !verbose {
+			return
+		}
+		printShardProgress(finished, total, scan)
+	})
+
+	// Remember how long each shard took so the slowest go first next time
+	for _, scan := range scans {
+		if scan.Err == nil && scan.Duration > 0 {
+			timings[scan.Spec] = scan.Duration.Seconds()
+		}
+	}
+	timings.save()
+
+	return mergeFolderScans(scans)
+}
+
+// printShardProgress prints one finished shard and the files it found
+func printShardProgress(finished int, total int, scan FolderScan) {
+	status := "✓"
+	if scan.Err != nil {
+		status = "⛔"
+	}
+	fmt.Printf("  [%d/%d] %s %s - %d file(s) (%.1fs)\n", finished, total, status, scan.Spec, len(scan.Records), scan.Duration.Seconds())
+	if scan.Err != nil {
+		fmt.Printf("        ✗ %v\n", scan.Err)
+	}
+
+	for i, record := range scan.Records {
+		if i < 5 {
+			fmt.Printf("        [%s] %s\n", strings.ToUpper(record.Action), recordLocalPath(record))
+		}
+	}
+	if len(scan.Records) > 5 {
+		fmt.Printf("        ... and %d more\n", len(scan.Records)-5)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScanShardsParallelErrors(t *testing.T) {
+	exit1 := errors.New("exit status 1")
+
+	tests := []struct {
+		name      string
+		response  FakeResponse
+		wantFiles int
+		wantErr   string
+	}{
+		{
+			name:      "files to reconcile",
+			response:  FakeResponse{Stdout: "... depotFile //depot/Game/Content/a.uasset\n... clientFile /ws/Content/a.uasset\n... action edit\n\n"},
+			wantFiles: 2,
+		},
+		{
+			name:      "nothing to reconcile is clean",
+			response:  FakeResponse{Stderr: "//depot/Game/Source/... - no file(s) to reconcile.\n", Err: exit1},
+			wantFiles: 1,
+		},
+		{
+			name:      "failed shard is reported",
+			response:  FakeResponse{Stderr: "Your session has expired, please login again.\n", Err: exit1},
+			wantFiles: 1,
+			wantErr:   "reconcile of /ws/Source/... failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, fake := setupFakeWorkspace(t)
+			fake.On("-ztag reconcile -n /ws/Content/...", FakeResponse{Stdout: "... depotFile //depot/Game/Content/b.uasset\n... clientFile /ws/Content/b.uasset\n... action add\n\n"})
+			fake.On("-ztag reconcile -n /ws/Source/...", tt.response)
+
+			shards := []scanShard{{Folder: "/ws", Spec: "/ws/Content/..."}, {Folder: "/ws", Spec: "/ws/Source/..."}}
+			scans := scanShardsParallel(context.Background(), shards, 2, nil)
+			records, err := mergeFolderScans(scans)
+
+			if len(records) != tt.wantFiles {
+				t.Errorf("merged %d file(s), want %d", len(records), tt.wantFiles)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("mergeFolderScans() error = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr) || isInterrupted(context.Background(), err)) {
+				t.Errorf("mergeFolderScans() error = %v, want a failure containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
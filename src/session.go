@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// EDITOR LAUNCH
+// ============================================================================
+
+// findProjectFile returns the .uproject to open: the configured one, or the
+// first found at the workspace root, in Project/, or one level down
+func findProjectFile(p4Info *P4Info, config *Config) (string, error) {
+	if config.ProjectFile != "" {
+		return config.ProjectFile, nil
+	}
+
+	patterns := []string{
+		filepath.Join(p4Info.ClientRoot, "*.uproject"),
+		filepath.Join(p4Info.ClientRoot, "Project", "*.uproject"),
+		filepath.Join(p4Info.ClientRoot, "*", "*.uproject"),
+	}
+	for _, pattern := range patterns {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return matches[0], nil
+		}
+	}
+
+	return "", fmt.Errorf("no .uproject found under %s - set project_file in %s", p4Info.ClientRoot, getConfigPath())
+}
+
+// launchEditor starts the Unreal Editor on the project without waiting for
+// it. Without an editor_path in the config, Windows opens the .uproject with
+// its registered application.
+func launchEditor(p4Info *P4Info, config *Config) error {
+	projectFile, err := findProjectFile(p4Info, config)
+	if err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	switch {
+	case config.EditorPath != "":
+		cmd = exec.Command(config.EditorPath, projectFile)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", projectFile)
+	default:
+		return fmt.Errorf("no editor configured - set editor_path in %s", getConfigPath())
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to launch editor: %v", err)
+	}
+	fmt.Printf("🚀 Launched editor on %s\n", projectFile)
+
+	// The editor outlives this call; don't leave a zombie behind
+	go cmd.Wait()
+	return nil
+}
+
+// ============================================================================
+// SESSION REVIEW
+// ============================================================================
+
+// reviewEditorSession shows what was opened since the baseline, split into
+// files still unchanged and files really edited, and offers to revert the
+// unchanged ones. Files already checked out at baseline time are left alone.
+func reviewEditorSession(ctx context.Context, baseline *Baseline, reader *bufio.Reader) error {
+	fmt.Println("\n📊 Editor Session Review")
+	fmt.Println("─────────────────────────────────────")
+	fmt.Printf("Baseline: '%s' at %s (%d file(s) already checked out)\n", baseline.Name, baseline.Timestamp.Format("2006-01-02 15:04:05"), len(baseline.Files))
+
+	unchanged, edited, err := getSessionChanges(ctx, baseline)
+	if err != nil {
+		return err
+	}
+
+	total := len(unchanged) + len(edited)
+	fmt.Printf("Opened since baseline:       %d\n", total)
+	fmt.Printf("  Unchanged since baseline:  %d (opened by the editor, never modified)\n", len(unchanged))
+	fmt.Printf("  Really edited:             %d\n", len(edited))
+	fmt.Println("─────────────────────────────────────")
+
+	if total == 0 {
+		fmt.Println("✓ Nothing was opened since the baseline.")
+		return nil
+	}
+
+	if len(edited) > 0 {
+		fmt.Println("\n✓ Really Edited (kept):")
+		printPathList(edited, 10)
+	}
+
+	if len(unchanged) == 0 {
+		fmt.Println("\n✓ No unchanged files to clean up.")
+		return nil
+	}
+
+	fmt.Println("\n⚠️  Unchanged Since Baseline:")
+	printPathList(unchanged, 20)
+
+	fmt.Printf("\n⚠️  This will revert %d unchanged file(s) opened during the session.\n", len(unchanged))
+	fmt.Println("   Files checked out before the baseline are NOT touched.")
+	fmt.Print("Proceed? (yes/no): ")
+
+	response, _ := reader.ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+	if response != "yes" && response != "y" {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	// revert -a double-checks each file is still unchanged
+	fmt.Println("\nReverting unchanged session files...")
+	result := runP4Batch(ctx, unchanged, "revert", "-a")
+	printBatchSummary(result)
+
+	fmt.Println("\n✓ Done! Your real edits and earlier checkouts remain checked out.")
+	return nil
+}
+
+// printPathList prints paths as bullets, up to limit of them
+func printPathList(paths []string, limit int) {
+	for i, path := range paths {
+		if i >= limit {
+			fmt.Printf("  ... and %d more\n", len(paths)-limit)
+			break
+		}
+		fmt.Printf("  • %s\n", path)
+	}
+}
+
+// ============================================================================
+// SNAPSHOT HISTORY
+// ============================================================================
+
+// printSnapshots lists the snapshots of a workspace, numbered oldest first
+func printSnapshots(history *BaselineHistory) {
+	for i, snapshot := range history.Snapshots {
+		fmt.Printf("  %2d. %-24s %s  %d file(s)\n", i+1, truncate(snapshot.Name, 24), snapshot.Timestamp.Format("2006-01-02 15:04:05"), len(snapshot.Files))
+	}
+}
+
+// pickSnapshot asks for a snapshot by number; Enter picks the default index
+func pickSnapshot(history *BaselineHistory, prompt string, defaultIndex int, reader *bufio.Reader) (*Baseline, bool) {
+	fmt.Printf("%s (1-%d, Enter = %d): ", prompt, len(history.Snapshots), defaultIndex+1)
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(input)
+
+	index := defaultIndex
+	if input != "" {
+		n, err := strconv.Atoi(input)
+		if err != nil || n < 1 || n > len(history.Snapshots) {
+			fmt.Println("Invalid snapshot.")
+			return nil, false
+		}
+		index = n - 1
+	}
+	return &history.Snapshots[index], true
+}
+
+// selectBaseline picks the snapshot to review against, the newest by default
+func selectBaseline(p4Info *P4Info, reader *bufio.Reader) (*Baseline, error) {
+	history, err := loadBaselineHistory(p4Info)
+	if err != nil {
+		return nil, err
+	}
+	if len(history.Snapshots) == 0 {
+		return nil, fmt.Errorf("no baseline found for %s - capture one first", p4Info.ClientName)
+	}
+	if len(history.Snapshots) == 1 {
+		return &history.Snapshots[0], nil
+	}
+
+	fmt.Println("\nSnapshots:")
+	printSnapshots(history)
+	baseline, ok := pickSnapshot(history, "\nReview against snapshot", len(history.Snapshots)-1, reader)
+	if !ok {
+		return nil, errCancelled
+	}
+	return baseline, nil
+}
+
+// showSnapshotDiff lists the snapshots of the workspace and compares two of
+// them: files opened, files no longer opened and files moved between changelists
+func showSnapshotDiff(p4Info *P4Info, reader *bufio.Reader) error {
+	history, err := loadBaselineHistory(p4Info)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n🕘 Snapshots of %s on %s\n", p4Info.ClientName, p4Info.ServerAddr)
+	fmt.Println("─────────────────────────────────────")
+	if len(history.Snapshots) < 2 {
+		printSnapshots(history)
+		fmt.Println("\nCapture at least two snapshots to compare them.")
+		return nil
+	}
+	printSnapshots(history)
+	fmt.Println()
+
+	last := len(history.Snapshots) - 1
+	from, ok := pickSnapshot(history, "Compare from", last-1, reader)
+	if !ok {
+		return nil
+	}
+	to, ok := pickSnapshot(history, "Compare to", last, reader)
+	if !ok {
+		return nil
+	}
+
+	diff := diffBaselines(from, to)
+	fmt.Printf("\n'%s' → '%s'\n", from.Name, to.Name)
+	fmt.Println("─────────────────────────────────────")
+	fmt.Printf("  Added:              %d\n", len(diff.Added))
+	fmt.Printf("  Removed:            %d\n", len(diff.Removed))
+	fmt.Printf("  Changelist moves:   %d\n", len(diff.Moved))
+
+	if len(diff.Added) > 0 {
+		fmt.Println("\n➕ Added (opened since):")
+		printPathList(diff.Added, 20)
+	}
+	if len(diff.Removed) > 0 {
+		fmt.Println("\n➖ Removed (reverted or submitted since):")
+		printPathList(diff.Removed, 20)
+	}
+	if len(diff.Moved) > 0 {
+		fmt.Println("\n🔀 Moved between changelists:")
+		for i, move := range diff.Moved {
+			if i >= 20 {
+				fmt.Printf("  ... and %d more\n", len(diff.Moved)-20)
+				break
+			}
+			fmt.Printf("  • %s: %s → %s\n", move.File, move.From, move.To)
+		}
+	}
+	if len(diff.Added)+len(diff.Removed)+len(diff.Moved) == 0 {
+		fmt.Println("\n✓ The snapshots are identical.")
+	}
+
+	return nil
+}
+
+// ============================================================================
+// MENU
+// ============================================================================
+
+// showEditorSessionMenu runs the baseline workflow: capture the checked-out
+// files, launch the editor, then clean up what the editor opened needlessly
+func showEditorSessionMenu(p4Info *P4Info, config *Config, reader *bufio.Reader) {
+	for {
+		fmt.Println("\n📸 EDITOR SESSION (HIJACK BASELINE)")
+		fmt.Println("─────────────────────────────────────")
+		fmt.Printf("  Workspace: %s on %s\n", p4Info.ClientName, p4Info.ServerAddr)
+		if history, err := loadBaselineHistory(p4Info); err == nil && len(history.Snapshots) > 0 {
+			baseline := history.Snapshots[len(history.Snapshots)-1]
+			fmt.Printf("  Baseline:  '%s' at %s, %d file(s) checked out (%d snapshot(s) kept)\n", baseline.Name, baseline.Timestamp.Format("2006-01-02 15:04:05"), len(baseline.Files), len(history.Snapshots))
+		} else {
+			fmt.Println("  Baseline:  none")
+		}
+		fmt.Println()
+		fmt.Println("  1. Capture baseline & launch Unreal Editor")
+		fmt.Println("  2. Capture baseline only (launch the editor yourself)")
+		fmt.Println("  3. Review session & revert unchanged files")
+		fmt.Println("  4. Snapshot history & diff")
+		fmt.Println("  5. Clear all snapshots")
+		fmt.Println("  6. Back to main menu")
+		fmt.Print("\nEnter choice (1-6): ")
+
+		choice, _ := reader.ReadString('\n')
+		choice = strings.TrimSpace(choice)
+
+		switch choice {
+		case "1", "2":
+			fmt.Print("\nSnapshot name (Enter = current time): ")
+			name, _ := reader.ReadString('\n')
+
+			ctx, endOperation := beginOperation()
+			err := captureBaseline(ctx, p4Info, strings.TrimSpace(name))
+			endOperation()
+			if err != nil {
+				fmt.Printf("\nError: %v\n", err)
+				continue
+			}
+			if choice == "2" {
+				continue
+			}
+
+			if err := launchEditor(p4Info, config); err != nil {
+				fmt.Printf("\nError: %v\n", err)
+				continue
+			}
+			fmt.Print("\nPress Enter after closing the editor to review the session (or 'q' to review later): ")
+			input, _ := reader.ReadString('\n')
+			if strings.TrimSpace(strings.ToLower(input)) == "q" {
+				continue
+			}
+			fallthrough
+		case "3":
+			baseline, err := selectBaseline(p4Info, reader)
+			if err == nil {
+				ctx, endOperation := beginOperation()
+				err = reviewEditorSession(ctx, baseline, reader)
+				endOperation()
+			}
+			if err != nil {
+				fmt.Printf("\nError: %v\n", err)
+			}
+			fmt.Print("\nPress Enter to continue...")
+			reader.ReadString('\n')
+		case "4":
+			if err := showSnapshotDiff(p4Info, reader); err != nil {
+				fmt.Printf("\nError: %v\n", err)
+			}
+			fmt.Print("\nPress Enter to continue...")
+			reader.ReadString('\n')
+		case "5":
+			if err := clearBaseline(p4Info); err != nil {
+				fmt.Printf("\nError: %v\n", err)
+			}
+		case "6":
+			return
+		default:
+			fmt.Println("Invalid choice.")
+		}
+	}
+}
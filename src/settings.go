@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// showSettingsMenu displays maintenance commands for the current workspace
+func showSettingsMenu(p4Info *P4Info, config *Config, reader *bufio.Reader) {
+	for {
+		cache := loadScanCache(p4Info.ClientName, p4Info.ServerAddr)
+
+		fmt.Println("\n⚙️  SETTINGS & MAINTENANCE")
+		fmt.Println("─────────────────────────────────────")
+		fmt.Printf("  Scan cache: %d file(s) (%s)\n", cache.Len(), cache.path)
+		fmt.Printf("  Watcher:    %s\n", activeWatcher.Status())
+		fmt.Printf("  Poller:     %s\n", activePoller.Status())
+		fmt.Printf("  Ignore:     %s + preset '%s'\n", strings.Join(p4IgnoreFileNames(context.Background()), ", "), ignorePreset)
+		fmt.Println()
+		fmt.Println("  1. Invalidate scan cache (next scan re-checks every file)")
+		if activeWatcher == nil {
+			fmt.Println("  2. Start workspace watcher (tracks touched files in the background)")
+		} else {
+			fmt.Println("  2. Stop workspace watcher")
+		}
+		if ignorePreset == ignorePresetUE {
+			fmt.Println("  3. Turn off UE ignore preset (Binaries/, Intermediate/, Saved/, ...)")
+		} else {
+			fmt.Println("  3. Turn on UE ignore preset (Binaries/, Intermediate/, Saved/, ...)")
+		}
+		if activePoller == nil {
+			fmt.Printf("  4. Start submit poller (warns when someone submits over your opened files, every %s)\n", config.PollInterval())
+		} else {
+			fmt.Println("  4. Stop submit poller")
+		}
+		fmt.Println("  5. Back to main menu")
+		fmt.Print("\nEnter choice (1-5): ")
+
+		choice, _ := reader.ReadString('\n')
+		choice = strings.TrimSpace(choice)
+
+		switch choice {
+		case "1":
+			count, err := invalidateScanCache(p4Info.ClientName, p4Info.ServerAddr)
+			if err != nil {
+				fmt.Printf("Error: failed to delete scan cache: %v\n", err)
+			} else {
+				fmt.Printf("✓ Scan cache cleared (%d file(s) forgotten)\n", count)
+			}
+		case "2":
+			if activeWatcher == nil {
+				if err := startActiveWatcher(p4Info.ClientRoot); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					continue
+				}
+				fmt.Println("✓ Watcher started - the next full scan primes it, later scans only check touched files")
+			} else {
+				stopActiveWatcher()
+				fmt.Println("✓ Watcher stopped")
+			}
+
+			// Remember the choice for the next launch
+			config.WatchWorkspace = activeWatcher != nil
+			if err := config.Save(); err != nil {
+				fmt.Printf("Warning: failed to save config: %v\n", err)
+			}
+		case "3":
+			if ignorePreset == ignorePresetUE {
+				ignorePreset = ignorePresetNone
+			} else {
+				ignorePreset = ignorePresetUE
+			}
+			config.IgnorePreset = ignorePreset
+			if err := config.Save(); err != nil {
+				fmt.Printf("Warning: failed to save config: %v\n", err)
+			}
+			fmt.Printf("✓ Ignore preset: %s\n", ignorePreset)
+		case "4":
+			if activePoller == nil {
+				if err := startActivePoller(config.PollInterval()); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					continue
+				}
+				fmt.Println("✓ Poller started - new submits to your opened files show up in the main menu")
+			} else {
+				stopActivePoller()
+				fmt.Println("✓ Poller stopped")
+			}
+
+			// Remember the choice for the next launch
+			config.PollSubmits = activePoller != nil
+			if err := config.Save(); err != nil {
+				fmt.Printf("Warning: failed to save config: %v\n", err)
+			}
+		case "5":
+			return
+		default:
+			fmt.Println("Invalid choice.")
+		}
+	}
+}
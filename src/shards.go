@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// shardSlowThreshold is how long a folder may take to scan before it is
+// split into subdirectory shards on the next run
+const shardSlowThreshold = 30 * time.Second
+
+// shardMaxDepth limits how many levels below a selected folder get split
+const shardMaxDepth = 3
+
+// scanShard is one unit of reconcile work: a file spec inside a selected folder
+type scanShard struct {
+	Folder string // the folder the user selected
+	Spec   string // the p4 file spec to reconcile, e.g. "C:\ws\Content\..."
+}
+
+// shardTimings maps shard specs to how many seconds they took last time
+type shardTimings map[string]float64
+
+// getShardTimingsPath returns the path to the shard timings file
+func getShardTimingsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".p4chimari_shards.json"), nil
+}
+
+// loadShardTimings loads remembered shard durations (empty if there are none)
+func loadShardTimings() shardTimings {
+	timings := make(shardTimings)
+
+	timingsPath, err := getShardTimingsPath()
+	if err != nil {
+		return timings
+	}
+
+	data, err := os.ReadFile(timingsPath)
+	if err != nil {
+		return timings
+	}
+
+	// A corrupt file only costs us the scheduling hint
+	if err := json.Unmarshal(data, &timings); err != nil {
+		return make(shardTimings)
+	}
+
+	return timings
+}
+
+// save writes the shard durations to disk, ignoring errors
+func (t shardTimings) save() {
+	timingsPath, err := getShardTimingsPath()
+	if err != nil {
+		return
+	}
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return
+	}
+
+	os.WriteFile(timingsPath, data, 0644)
+}
+
+// folderSpec returns the recursive reconcile spec for a folder
+func folderSpec(folder string) string {
+	return filepath.Join(folder, "...")
+}
+
+// planShards turns the selected folders into reconcile shards, slowest first.
+// The workspace root, and any folder whose last scan was slower than
+// shardSlowThreshold, is split into one shard per subdirectory plus one for
+// the files directly inside it. Shards never measured before are scheduled
+// ahead of measured ones so they get a timing as early as possible.
+func planShards(ctx context.Context, folders []string, clientRoot string, timings shardTimings) []scanShard {
+	var shards []scanShard
+
+	for _, folder := range folders {
+		isRoot := clientRoot != "" && filepath.Clean(folder) == filepath.Clean(clientRoot)
+		if isRoot || isSlowShard(folderSpec(folder), timings) {
+			shards = append(shards, splitFolder(ctx, folder, folder, timings, 1)...)
+		} else {
+			shards = append(shards, scanShard{Folder: folder, Spec: folderSpec(folder)})
+		}
+	}
+
+	sort.SliceStable(shards, func(i, j int) bool {
+		return shardWeight(shards[i], timings) > shardWeight(shards[j], timings)
+	})
+
+	return shards
+}
+
+// splitFolder shards dir by subdirectory, splitting slow subdirectories again
+// up to shardMaxDepth levels deep. Subdirectories come from both the local
+// disk and the depot ("p4 dirs -H"), so a directory deleted locally still
+// gets a shard and its deletes are reconciled.
+func splitFolder(ctx context.Context, selected string, dir string, timings shardTimings, depth int) []scanShard {
+	var localDirs []string
+	entries, err := os.ReadDir(dir)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			localDirs = append(localDirs, entry.Name())
+		}
+	}
+	depotDirs := depotSubdirs(ctx, dir)
+	if err != nil && len(depotDirs) == 0 {
+		// Let p4 report the problem for the whole folder
+		return []scanShard{{Folder: selected, Spec: folderSpec(dir)}}
+	}
+
+	// Files directly in dir are covered by a non-recursive wildcard
+	shards := []scanShard{{Folder: selected, Spec: filepath.Join(dir, "*")}}
+
+	for _, name := range mergeSubdirs(localDirs, depotDirs) {
+		subdir := filepath.Join(dir, name)
+		if depth < shardMaxDepth && isSlowShard(folderSpec(subdir), timings) {
+			shards = append(shards, splitFolder(ctx, selected, subdir, timings, depth+1)...)
+		} else {
+			shards = append(shards, scanShard{Folder: selected, Spec: folderSpec(subdir)})
+		}
+	}
+
+	return shards
+}
+
+// depotSubdirs returns the names of the depot directories directly in a local
+// directory that have files on the have list. Errors only cost the depot side
+// of the listing.
+func depotSubdirs(ctx context.Context, dir string) []string {
+	records, _ := runP4Tagged(ctx, "dirs", "-H", filepath.Join(dir, "*"))
+
+	var names []string
+	for _, r := range records {
+		if r["dir"] != "" {
+			names = append(names, path.Base(r["dir"]))
+		}
+	}
+	return names
+}
+
+// mergeSubdirs combines local and depot subdirectory names, keeping local
+// spelling when both list a directory (compared case-insensitively, as on
+// Windows workspaces)
+func mergeSubdirs(localDirs []string, depotDirs []string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, name := range append(append([]string{}, localDirs...), depotDirs...) {
+		key := strings.ToLower(name)
+		if !seen[key] {
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isSlowShard reports whether a spec took longer than shardSlowThreshold last time
+func isSlowShard(spec string, timings shardTimings) bool {
+	seconds, ok := timings[spec]
+	return ok && seconds > shardSlowThreshold.Seconds()
+}
+
+// shardWeight is the scheduling priority of a shard: its last duration, or
+// +Inf when it has never been measured
+func shardWeight(shard scanShard, timings shardTimings) float64 {
+	seconds, ok := timings[shard.Spec]
+	if !ok {
+		return math.Inf(1)
+	}
+	return seconds
+}
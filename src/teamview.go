@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TeamOpen is a file someone else has opened
+type TeamOpen struct {
+	DepotFile string
+	OtherOpen
+	FileType string
+	Mine     bool // also in one of our pending changelists
+}
+
+// ============================================================================
+// QUERY
+// ============================================================================
+
+// getOpenedByOthers returns the files under paths (depot files or folder
+// specs) that other users or other workspaces have opened, using batched
+// "p4 opened -a" calls
+func getOpenedByOthers(ctx context.Context, p4Info *P4Info, paths []string) ([]TeamOpen, error) {
+	var opens []TeamOpen
+	seen := make(map[string]bool)
+
+	for _, chunk := range chunkFiles(paths, batchMaxFiles, batchMaxBytes) {
+		if ctx.Err() != nil {
+			return opens, errCancelled
+		}
+
+		// Paths nobody has opened make p4 exit non-zero
+		records, err := runP4TaggedArgFile(ctx, chunk, "opened", "-a")
+		if err != nil && !isNoMatch(err) {
+			return opens, err
+		}
+
+		for _, r := range records {
+			if r["depotFile"] == "" || (r["user"] == p4Info.UserName && r["client"] == p4Info.ClientName) {
+				continue
+			}
+
+			// Overlapping folders and files report the same open twice
+			key := r["depotFile"] + "|" + r["user"] + "@" + r["client"]
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			change := r["change"]
+			if change == "" {
+				change = "default"
+			}
+			_, locked := r["ourLock"]
+			opens = append(opens, TeamOpen{
+				DepotFile: r["depotFile"],
+				OtherOpen: OtherOpen{
+					User:   r["user"],
+					Client: r["client"],
+					Change: change,
+					Action: r["action"],
+					Locked: locked || isExclusiveType(r["type"]),
+				},
+				FileType: r["type"],
+			})
+		}
+	}
+
+	return opens, nil
+}
+
+// groupOpensByUser groups opens by user, busiest user first
+func groupOpensByUser(opens []TeamOpen) ([]string, map[string][]TeamOpen) {
+	byUser := make(map[string][]TeamOpen)
+	for _, open := range opens {
+		byUser[open.User] = append(byUser[open.User], open)
+	}
+
+	users := make([]string, 0, len(byUser))
+	for user := range byUser {
+		users = append(users, user)
+		sort.Slice(byUser[user], func(i, j int) bool {
+			return byUser[user][i].DepotFile < byUser[user][j].DepotFile
+		})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if len(byUser[users[i]]) != len(byUser[users[j]]) {
+			return len(byUser[users[i]]) > len(byUser[users[j]])
+		}
+		return users[i] < users[j]
+	})
+
+	return users, byUser
+}
+
+// ============================================================================
+// SCREEN
+// ============================================================================
+
+// showWhoElseHasOpen shows, grouped by user, who else has opened the files in
+// our pending changelists and, optionally, in selected Content folders
+func showWhoElseHasOpen(ctx context.Context, p4Info *P4Info, reader *bufio.Reader) {
+	clearScreen()
+	printHeader()
+
+	fmt.Printf("WHO ELSE HAS THIS OPEN - %s\n", p4Info.ClientName)
+	fmt.Println("─────────────────────────────────────")
+
+	// Our pending files, from every changelist
+	pending := make(map[string]bool)
+	var paths []string
+	for _, files := range getChangelists(ctx) {
+		for _, file := range files {
+			if !pending[file] {
+				pending[file] = true
+				paths = append(paths, file)
+			}
+		}
+	}
+	fmt.Printf("  %d file(s) in your pending changelists\n", len(paths))
+
+	fmt.Print("\nAlso check Content folders? (y/n): ")
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
+	if input == "y" || input == "yes" {
+		config, _ := loadConfig()
+		folders, err := showFolderPicker(p4Info, config)
+		if err != nil {
+			fmt.Printf("Folders skipped: %v\n", err)
+		}
+		for _, folder := range folders {
+			paths = append(paths, folderSpec(folder))
+		}
+	}
+
+	if len(paths) == 0 {
+		fmt.Println("\nNothing to check - no pending files and no folders selected.")
+		return
+	}
+
+	fmt.Println("\n→ Checking other workspaces (p4 opened -a)...")
+	opens, err := getOpenedByOthers(ctx, p4Info, paths)
+	if err != nil {
+		fmt.Printf("  ⛔ %v - showing what was found so far\n", err)
+	}
+	for i := range opens {
+		opens[i].Mine = pending[opens[i].DepotFile]
+	}
+
+	if len(opens) == 0 {
+		fmt.Println("\n✓ Nobody else has these files open.")
+		return
+	}
+
+	users, byUser := groupOpensByUser(opens)
+	conflicts := 0
+	for _, open := range opens {
+		if open.Mine {
+			conflicts++
+		}
+	}
+
+	fmt.Printf("\n%d open(s) by %d other user(s)", len(opens), len(users))
+	if conflicts > 0 {
+		fmt.Printf(" - ⚠ %d on files YOU also have open", conflicts)
+	}
+	fmt.Println()
+
+	for _, user := range users {
+		fmt.Println("\n─────────────────────────────────────")
+		fmt.Printf("👤 %s (%d file(s))\n", user, len(byUser[user]))
+		for _, open := range byUser[user] {
+			marker := "  "
+			if open.Mine {
+				marker = "⚠ "
+			}
+			lock := ""
+			if open.Locked {
+				lock = " 🔒"
+			}
+			fmt.Printf("  %s[%-6s] CL %-8s %-20s %s%s\n", marker, open.Action, truncate(open.Change, 8), truncate(open.Client, 20), open.DepotFile, lock)
+		}
+	}
+
+	fmt.Println("\n⚠ = also in your pending changelists, 🔒 = exclusive (+l) or locked")
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// packageFileTag is the magic number at the start of every .uasset and .umap
+const packageFileTag = 0x9E2A83C1
+
+// packageCompareMaxBytes skips header comparison for packages bigger than this,
+// since both versions are held in memory
+const packageCompareMaxBytes = 256 << 20
+
+// PKG_FilterEditorOnly: the package was cooked without editor-only data
+const pkgFilterEditorOnly = 0x80000000
+
+// Object versions that add or remove package summary fields
+const (
+	ue4VerAddStringAssetReferencesMap       = 384
+	ue4VerSerializeTextInPackages           = 459
+	ue4VerAddedSearchableNames              = 510
+	ue4VerAddedPackageSummaryLocalizationID = 516
+	ue5VerAddSoftObjectPathList             = 1008
+	ue5VerMetadataSerializationOffset       = 1014
+	ue5VerVerseCells                        = 1015
+	ue5VerPackageSavedHash                  = 1016
+)
+
+// byteRange is a span of bytes in a file
+type byteRange struct {
+	Offset int
+	Length int
+}
+
+// isPackagePath reports whether a path is an Unreal package (.uasset or .umap)
+func isPackagePath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".uasset", ".umap":
+		return true
+	}
+	return false
+}
+
+// ============================================================================
+// PACKAGE SUMMARY
+// ============================================================================
+
+// packageReader reads little-endian package summary fields, remembering the
+// first read past the end of the data
+type packageReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// skip moves past n bytes
+func (r *packageReader) skip(n int) {
+	if r.err == nil && (n < 0 || r.pos+n > len(r.data)) {
+		r.err = fmt.Errorf("package summary truncated at offset %d", r.pos)
+	}
+	if r.err == nil {
+		r.pos += n
+	}
+}
+
+// int32 reads a signed 32-bit field
+func (r *packageReader) int32() int32 {
+	start := r.pos
+	r.skip(4)
+	if r.err != nil {
+		return 0
+	}
+	return int32(binary.LittleEndian.Uint32(r.data[start:]))
+}
+
+// fstring skips a serialized FString: a length, then ANSI bytes or, for a
+// negative length, UTF-16 code units
+func (r *packageReader) fstring() {
+	length := int(r.int32())
+	if length < 0 {
+		r.skip(-length * 2)
+	} else {
+		r.skip(length)
+	}
+}
+
+// volatileHeaderRanges parses the package summary at the start of a .uasset
+// or .umap and returns where the fields the editor rewrites on every save
+// are: the package GUID (before UE 5.4) or the saved hash (UE 5.4 and later)
+func volatileHeaderRanges(data []byte) ([]byteRange, error) {
+	r := &packageReader{data: data}
+
+	if uint32(r.int32()) != packageFileTag {
+		return nil, fmt.Errorf("not an Unreal package")
+	}
+
+	legacyVersion := r.int32()
+	if legacyVersion >= 0 || legacyVersion < -8 {
+		return nil, fmt.Errorf("unsupported package file version %d", legacyVersion)
+	}
+	if legacyVersion != -4 {
+		r.int32() // LegacyUE3Version
+	}
+	versionUE4 := r.int32()
+	var versionUE5 int32
+	if legacyVersion <= -8 {
+		versionUE5 = r.int32()
+	}
+	r.int32() // FileVersionLicenseeUE4
+
+	var ranges []byteRange
+	if versionUE5 >= ue5VerPackageSavedHash {
+		ranges = append(ranges, byteRange{Offset: r.pos, Length: 20})
+		r.skip(20) // SavedHash
+		r.int32()  // TotalHeaderSize
+	}
+
+	// Custom versions: enum tags, GUIDs with names, or plain GUIDs
+	if legacyVersion <= -2 {
+		count := int(r.int32())
+		for i := 0; i < count && r.err == nil; i++ {
+			switch {
+			case legacyVersion == -2:
+				r.skip(8)
+			case legacyVersion <= -6:
+				r.skip(20)
+			default:
+				r.skip(20)
+				r.fstring()
+			}
+		}
+	}
+	if versionUE5 < ue5VerPackageSavedHash {
+		r.int32() // TotalHeaderSize
+	}
+
+	r.fstring() // PackageName
+	flags := uint32(r.int32())
+	editorOnly := flags&pkgFilterEditorOnly == 0
+
+	r.skip(8) // NameCount, NameOffset
+	if versionUE5 >= ue5VerAddSoftObjectPathList {
+		r.skip(8)
+	}
+	if editorOnly && versionUE4 >= ue4VerAddedPackageSummaryLocalizationID {
+		r.fstring() // LocalizationId
+	}
+	if versionUE4 >= ue4VerSerializeTextInPackages {
+		r.skip(8) // GatherableTextData
+	}
+	r.skip(16) // ExportCount, ExportOffset, ImportCount, ImportOffset
+	if versionUE5 >= ue5VerVerseCells {
+		r.skip(16)
+	}
+	if versionUE5 >= ue5VerMetadataSerializationOffset {
+		r.skip(4)
+	}
+	r.skip(4) // DependsOffset
+	if versionUE4 >= ue4VerAddStringAssetReferencesMap {
+		r.skip(8) // SoftPackageReferences
+	}
+	if versionUE4 >= ue4VerAddedSearchableNames {
+		r.skip(4)
+	}
+	r.skip(4) // ThumbnailTableOffset
+
+	if versionUE5 < ue5VerPackageSavedHash {
+		ranges = append(ranges, byteRange{Offset: r.pos, Length: 16})
+		r.skip(16) // Guid
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return ranges, nil
+}
+
+// packagesEquivalent reports whether two versions of a package are identical
+// apart from their volatile header fields
+func packagesEquivalent(local []byte, depot []byte) bool {
+	if len(local) != len(depot) {
+		return false
+	}
+
+	localRanges, err := volatileHeaderRanges(local)
+	if err != nil {
+		return false
+	}
+	depotRanges, err := volatileHeaderRanges(depot)
+	if err != nil || len(localRanges) != len(depotRanges) {
+		return false
+	}
+
+	start := 0
+	for i, masked := range localRanges {
+		if depotRanges[i] != masked {
+			return false
+		}
+		if !bytes.Equal(local[start:masked.Offset], depot[start:masked.Offset]) {
+			return false
+		}
+		start = masked.Offset + masked.Length
+	}
+	return bytes.Equal(local[start:], depot[start:])
+}
+
+// ============================================================================
+// DETECTION
+// ============================================================================
+
+// findHeaderOnlyPackages returns which of the given opened depot files are
+// .uasset/.umap packages that only differ from their have revision in
+// volatile header fields, i.e. were re-saved by the editor without changes.
+// Sizes are compared first, so only same-size packages are printed from the
+// server. Stops early (with what it found) when ctx is cancelled.
+func findHeaderOnlyPackages(ctx context.Context, depotFiles []string) map[string]bool {
+	headerOnly := make(map[string]bool)
+
+	var packages []string
+	for _, depotFile := range depotFiles {
+		if isPackagePath(depotFile) {
+			packages = append(packages, depotFile)
+		}
+	}
+
+	var records []FileRecord
+	for _, chunk := range chunkFiles(packages, batchMaxFiles, batchMaxBytes) {
+		if ctx.Err() != nil {
+			return headerOnly
+		}
+
+		lines := make([]string, len(chunk))
+		for i, depotFile := range chunk {
+			lines[i] = depotFile + "#have"
+		}
+
+		// Adds have no have revision and make fstat exit non-zero
+		fstats, _ := runP4TaggedArgFile(ctx, lines, "fstat", "-Ol", "-T", "depotFile,clientFile,haveRev,fileSize")
+		for _, r := range fstats {
+			if record := fileRecordFrom(r); record.DepotFile != "" && record.HaveRev > 0 {
+				records = append(records, record)
+			}
+		}
+	}
+	resolveLocalPaths(ctx, records)
+
+	for _, record := range records {
+		if ctx.Err() != nil {
+			break
+		}
+
+		info, err := os.Stat(recordLocalPath(record))
+		if err != nil || !info.Mode().IsRegular() || info.Size() != record.FileSize || info.Size() > packageCompareMaxBytes {
+			continue
+		}
+
+		local, err := os.ReadFile(recordLocalPath(record))
+		if err != nil {
+			continue
+		}
+		depot, err := runP4(ctx, "print", "-q", fmt.Sprintf("%s#%d", record.DepotFile, record.HaveRev))
+		if err != nil {
+			continue
+		}
+
+		if packagesEquivalent(local, depot) {
+			headerOnly[record.DepotFile] = true
+		}
+	}
+
+	return headerOnly
+}
+
+// splitHeaderOnlyPackages moves opened files whose only change is a re-saved
+// package header out of files, marking them as hijacked
+func splitHeaderOnlyPackages(ctx context.Context, files []ModifiedFile) ([]ModifiedFile, []ModifiedFile) {
+	var depotFiles []string
+	for _, file := range files {
+		depotFiles = append(depotFiles, file.DepotPath)
+	}
+
+	headerOnly := findHeaderOnlyPackages(ctx, depotFiles)
+	if len(headerOnly) == 0 {
+		return files, nil
+	}
+
+	changed := []ModifiedFile{}
+	var hijacked []ModifiedFile
+	for _, file := range files {
+		if headerOnly[file.DepotPath] {
+			file.HasChanges = false
+			file.HeaderOnly = true
+			hijacked = append(hijacked, file)
+		} else {
+			changed = append(changed, file)
+		}
+	}
+	return changed, hijacked
+}
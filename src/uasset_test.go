@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVolatileHeaderRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []byteRange
+		wantErr bool
+	}{
+		{"UE4 package GUID", testPackage(-7, 1, ""), []byteRange{{Offset: 97, Length: 16}}, false},
+		{"UE5.4 saved hash", testPackage(-8, 1, ""), []byteRange{{Offset: 24, Length: 20}}, false},
+		{"not a package", []byte("plain text file, not a package"), nil, true},
+		{"truncated", testPackage(-7, 1, "")[:60], nil, true},
+		{"unsupported version", append([]byte{0xC1, 0x83, 0x2A, 0x9E}, 0x01, 0, 0, 0), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := volatileHeaderRanges(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("volatileHeaderRanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("volatileHeaderRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackagesEquivalent(t *testing.T) {
+	tests := []struct {
+		name  string
+		local []byte
+		depot []byte
+		want  bool
+	}{
+		{"identical", testPackage(-7, 1, "body"), testPackage(-7, 1, "body"), true},
+		{"new GUID only", testPackage(-7, 1, "body"), testPackage(-7, 2, "body"), true},
+		{"new saved hash only", testPackage(-8, 1, "body"), testPackage(-8, 2, "body"), true},
+		{"body changed", testPackage(-7, 1, "body"), testPackage(-7, 2, "bodY"), false},
+		{"size changed", testPackage(-7, 1, "body"), testPackage(-7, 1, "body2"), false},
+		{"not packages", []byte("same size A"), []byte("same size B"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := packagesEquivalent(tt.local, tt.depot); got != tt.want {
+				t.Errorf("packagesEquivalent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,9 +2,9 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 )
 
@@ -14,16 +14,22 @@ type ChangelistCategory struct {
 	Files []string
 }
 
-func showViewChanges(p4Info *P4Info) {
+func showViewChanges(ctx context.Context, p4Info *P4Info) {
 	reader := bufio.NewReader(os.Stdin)
 
 	for {
+		// Ctrl+C leaves the view and returns to the main menu
+		if ctx.Err() != nil {
+			fmt.Println("Cancelled.")
+			return
+		}
+
 		// Clear screen and show header
 		clearScreen()
 		printHeader()
 
 		// Get data
-		changelists := getChangelists()
+		changelists := getChangelists(ctx)
 		unsavedAssets := getUnsavedAssets()
 		uncontrolled := getUncontrolledFiles()
 
@@ -35,6 +41,7 @@ func showViewChanges(p4Info *P4Info) {
 		// Section header
 		fmt.Printf("VIEW CHANGES - %s\n", p4Info.ClientName)
 		fmt.Println("─────────────────────────────────────")
+		printSubmitAlerts(20)
 		fmt.Println()
 
 		// Menu options
@@ -83,6 +90,10 @@ func showViewChanges(p4Info *P4Info) {
 		fmt.Println("  [r]   - Refresh")
 		fmt.Println("  [c]   - Checkout unsaved assets")
 		fmt.Println("  [o]   - Reconcile uncontrolled")
+		fmt.Println("  [w]   - Who else has these files open")
+		if len(activePoller.Alerts()) > 0 {
+			fmt.Println("  [a]   - Acknowledge new-submit alerts")
+		}
 		fmt.Println("  [q]   - Quit")
 		fmt.Print("\nEnter command: ")
 
@@ -95,24 +106,30 @@ func showViewChanges(p4Info *P4Info) {
 			continue
 		} else if input == "c" {
 			if len(unsavedAssets) > 0 {
-				checkoutFilesList(unsavedAssets)
+				checkoutFilesList(ctx, unsavedAssets)
 				fmt.Print("\nPress Enter to continue...")
 				reader.ReadString('\n')
 			}
 		} else if input == "o" {
-			reconcileFiles()
+			reconcileFiles(ctx)
+			fmt.Print("\nPress Enter to continue...")
+			reader.ReadString('\n')
+		} else if input == "a" {
+			activePoller.Acknowledge()
+		} else if input == "w" {
+			showWhoElseHasOpen(ctx, p4Info, reader)
 			fmt.Print("\nPress Enter to continue...")
 			reader.ReadString('\n')
 		} else if input >= "1" && input <= "9" {
 			idx := int(input[0] - '1')
 			if idx < len(categories) {
-				showCategoryFiles(categories[idx], reader)
+				showCategoryFiles(ctx, categories[idx], reader)
 			}
 		}
 	}
 }
 
-func showCategoryFiles(category ChangelistCategory, reader *bufio.Reader) {
+func showCategoryFiles(ctx context.Context, category ChangelistCategory, reader *bufio.Reader) {
 	// Clear screen and show header
 	clearScreen()
 	printHeader()
@@ -139,48 +156,26 @@ func showCategoryFiles(category ChangelistCategory, reader *bufio.Reader) {
 	input = strings.TrimSpace(strings.ToLower(input))
 
 	if input == "c" && category.Name == "Unsaved Assets" {
-		checkoutFilesList(category.Files)
+		checkoutFilesList(ctx, category.Files)
 		fmt.Print("\nPress Enter to continue...")
 		reader.ReadString('\n')
 	}
 }
 
-func getChangelists() map[string][]string {
+func getChangelists(ctx context.Context) map[string][]string {
 	result := make(map[string][]string)
 
-	cmd := exec.Command("p4", "opened", "-C")
-	output, err := cmd.Output()
+	records, err := p4Opened(ctx)
 	if err != nil {
 		return result
 	}
 
-	lines := strings.Split(string(output), "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Parse: //depot/path#1 - edit change 12345 (text)
-		if strings.Contains(line, " - ") {
-			parts := strings.Fields(line)
-			changeNum := "default"
-
-			for i, part := range parts {
-				if part == "change" && i+1 < len(parts) {
-					changeNum = parts[i+1]
-					break
-				}
-			}
-
-			if strings.Contains(line, "default change") {
-				changeNum = "default"
-			}
-
-			filePath := strings.Split(line, "#")[0]
-			result[changeNum] = append(result[changeNum], filePath)
+	for _, record := range records {
+		changeNum := record.Change
+		if changeNum == "" {
+			changeNum = "default"
 		}
+		result[changeNum] = append(result[changeNum], record.DepotFile)
 	}
 
 	return result
@@ -198,18 +193,10 @@ func getUncontrolledFiles() []string {
 	return []string{}
 }
 
-func checkoutFilesList(files []string) {
+func checkoutFilesList(ctx context.Context, files []string) {
 	fmt.Println("\nChecking out files...")
-	for _, file := range files {
-		fmt.Printf("  p4 edit %s\n", file)
-		cmd := exec.Command("p4", "edit", file)
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			fmt.Printf("    Error: %v\n", err)
-		} else {
-			fmt.Printf("    %s\n", strings.TrimSpace(string(output)))
-		}
-	}
+	result := runP4Batch(ctx, files, "edit")
+	printBatchSummary(result)
 	fmt.Println("\nDone!")
 }
 
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// errWatcherUnsupported is returned on platforms without a native watcher
+var errWatcherUnsupported = errors.New("workspace watching is not supported on this platform")
+
+// WorkspaceWatcher keeps a live set of files touched under the workspace
+// root. The set only becomes a complete picture of a folder once a full scan
+// of that folder has run while the watcher was already watching (see prime);
+// until then scans fall back to reconcile or digest mode.
+type WorkspaceWatcher struct {
+	Root    string
+	Started time.Time
+
+	mu      sync.Mutex
+	touched map[string]time.Time
+	primed  []string  // folders with a full scan since the watcher started
+	lostAt  time.Time // when events were last dropped
+	failed  bool      // the platform watch died; nothing is recorded any more
+	stop    func()
+}
+
+// activeWatcher is the running watcher, or nil when watching is off
+var activeWatcher *WorkspaceWatcher
+
+// startWorkspaceWatcher starts watching root recursively in the background
+func startWorkspaceWatcher(root string) (*WorkspaceWatcher, error) {
+	watcher := &WorkspaceWatcher{
+		Root:    root,
+		Started: time.Now(),
+		touched: make(map[string]time.Time),
+	}
+
+	stop, err := watchTree(root, watcher.touch, watcher.lost)
+	if err != nil {
+		return nil, err
+	}
+	watcher.stop = stop
+
+	return watcher, nil
+}
+
+// startActiveWatcher starts the workspace watcher used by scans
+func startActiveWatcher(root string) error {
+	watcher, err := startWorkspaceWatcher(root)
+	if err != nil {
+		return fmt.Errorf("failed to watch workspace: %v", err)
+	}
+	activeWatcher = watcher
+	return nil
+}
+
+// stopActiveWatcher stops the workspace watcher, if one is running
+func stopActiveWatcher() {
+	activeWatcher.Stop()
+	activeWatcher = nil
+}
+
+// Stop stops watching; the watcher can't be used afterwards
+func (w *WorkspaceWatcher) Stop() {
+	if w == nil || w.stop == nil {
+		return
+	}
+	w.stop()
+	w.stop = nil
+}
+
+// touch records a changed, created, renamed or deleted path. Platform code
+// passes "dir/..." for directories that appeared or disappeared as a whole.
+func (w *WorkspaceWatcher) touch(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.touched[filepath.Clean(path)] = time.Now()
+}
+
+// lost records that events were dropped (queue overflow or watch limit).
+// Every folder needs a full scan again before the set is trusted. failed
+// means the watch itself died and the watcher can no longer be used.
+func (w *WorkspaceWatcher) lost(failed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lostAt = time.Now()
+	w.primed = nil
+	if failed {
+		w.failed = true
+	}
+}
+
+// candidates returns the touched paths under folders, sorted. ok is false when
+// any folder has not been primed by a full scan, i.e. a full scan is needed.
+func (w *WorkspaceWatcher) candidates(folders []string, caseSensitive bool) (paths []string, ok bool) {
+	if w == nil {
+		return nil, false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.failed {
+		return nil, false
+	}
+	for _, folder := range folders {
+		if !w.isPrimed(folder, caseSensitive) {
+			return nil, false
+		}
+	}
+
+	for path := range w.touched {
+		for _, folder := range folders {
+			if pathHasPrefix(path, folder, caseSensitive) {
+				paths = append(paths, path)
+				break
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	return paths, true
+}
+
+// isPrimed reports whether folder is inside a primed folder (mu must be held)
+func (w *WorkspaceWatcher) isPrimed(folder string, caseSensitive bool) bool {
+	for _, primed := range w.primed {
+		if pathHasPrefix(folder, primed, caseSensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// prime records the result of a full scan of folders that started at
+// scanStart. Files the scan found are added to the touched set so they keep
+// being reported; the folders are then served from the watcher.
+func (w *WorkspaceWatcher) prime(folders []string, scanStart time.Time, dirty []string) {
+	if w == nil || w.stop == nil || scanStart.Before(w.Started) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Events lost during the scan make it useless as a starting point
+	if w.failed || (!w.lostAt.IsZero() && !w.lostAt.Before(scanStart)) {
+		return
+	}
+
+	for _, path := range dirty {
+		if _, ok := w.touched[filepath.Clean(path)]; !ok {
+			w.touched[filepath.Clean(path)] = scanStart
+		}
+	}
+	for _, folder := range folders {
+		w.primed = append(w.primed, filepath.Clean(folder))
+	}
+}
+
+// settle records the result of a scan of the touched paths under folders
+// that started at scanStart. Paths touched before the scan are dropped unless
+// the scan found them dirty, so the set does not grow back toward a full scan
+// over a session; paths touched while it ran are kept for the next scan.
+func (w *WorkspaceWatcher) settle(folders []string, scanStart time.Time, dirty []string, caseSensitive bool) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for path, touchedAt := range w.touched {
+		if touchedAt.Before(scanStart) && inFolders(path, folders, caseSensitive) {
+			delete(w.touched, path)
+		}
+	}
+	for _, path := range dirty {
+		if _, ok := w.touched[filepath.Clean(path)]; !ok {
+			w.touched[filepath.Clean(path)] = scanStart
+		}
+	}
+}
+
+// Status describes the watcher for menus
+func (w *WorkspaceWatcher) Status() string {
+	if w == nil {
+		return "stopped"
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.failed {
+		return fmt.Sprintf("stopped - watching %s failed", w.Root)
+	}
+	status := fmt.Sprintf("watching %s since %s, %d touched path(s)", w.Root, w.Started.Format("15:04:05"), len(w.touched))
+	if len(w.primed) == 0 {
+		status += ", waiting for a full scan"
+	}
+	return status
+}
+
+// ============================================================================
+// SCANNING WITH THE WATCHER
+// ============================================================================
+
+// getModifiedFilesFromWatcher checks only the touched paths with a batched
+// "p4 reconcile -n", which is near-instant compared with a full scan
+func getModifiedFilesFromWatcher(ctx context.Context, paths []string, verbose bool) ([]ModifiedFile, error) {
+	return reconcilePaths(ctx, paths, "touched", verbose)
+}
+
+// reconcilePaths runs a batched "p4 -x - reconcile -n" over local paths
+// (files or "dir/..." entries); kind describes them in progress output
+func reconcilePaths(ctx context.Context, paths []string, kind string, verbose bool) ([]ModifiedFile, error) {
+	files := []ModifiedFile{}
+	if len(paths) == 0 {
+		return files, nil
+	}
+
+	// A "dir/..." entry and a file inside it can report the same file
+	seen := make(map[string]bool)
+
+	chunks := chunkFiles(paths, batchMaxFiles, batchMaxBytes)
+	for i, chunk := range chunks {
+		if verbose {
+			fmt.Printf("  Executing: p4 -ztag -x - reconcile -n (%d %s path(s), batch %d/%d)\n", len(chunk), kind, i+1, len(chunks))
+		}
+
+		lines := make([]string, len(chunk))
+		for j, path := range chunk {
+			lines[j] = escapeP4Path(path)
+		}
+
+		records, err := runP4TaggedArgFile(ctx, lines, "reconcile", "-n")
+		var found []FileRecord
+		for _, r := range records {
+			record := fileRecordFrom(r)
+			if record.DepotFile != "" && record.Action != "" && !seen[record.DepotFile] {
+				seen[record.DepotFile] = true
+				found = append(found, record)
+			}
+		}
+		resolveLocalPaths(ctx, found)
+
+		for _, record := range found {
+			files = append(files, ModifiedFile{
+				Path:        recordLocalPath(record),
+				Action:      record.Action,
+				HasChanges:  true,
+				IsOpened:    false,
+				FileDetails: detailsFromRecord(record),
+			})
+		}
+
+		// Paths outside the view or already clean make p4 exit non-zero
+		if err != nil && !isNoMatch(err) {
+			return files, err
+		}
+	}
+
+	return files, nil
+}
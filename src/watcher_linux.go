@@ -0,0 +1,154 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask is the set of events that can mean a file's content changed
+const inotifyMask = syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_ATTRIB |
+	syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_DELETE_SELF
+
+// watchTree watches root and every directory below it with inotify. onChange
+// gets each touched path; onLost is called when events were dropped, with
+// failed set when watching stopped for good. The returned function stops
+// watching.
+func watchTree(root string, onChange func(path string), onLost func(failed bool)) (func(), error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	// A non-blocking fd goes through the runtime poller, so Close unblocks Read
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	tree := &inotifyTree{fd: fd, dirs: make(map[int32]string), onLost: onLost}
+	if err := tree.addTree(root, nil); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	var once sync.Once
+	stopped := make(chan struct{})
+	stop := func() {
+		once.Do(func() {
+			close(stopped)
+			file.Close()
+		})
+	}
+
+	go func() {
+		tree.readEvents(file, onChange)
+		select {
+		case <-stopped:
+		default:
+			onLost(true)
+		}
+	}()
+
+	return stop, nil
+}
+
+// inotifyTree maps watch descriptors to the directories they watch
+type inotifyTree struct {
+	fd     int
+	dirs   map[int32]string
+	onLost func(failed bool)
+}
+
+// addTree adds a watch for dir and all its subdirectories. When onFile is set
+// it is called for every file found, which covers files created inside a new
+// directory before its watch was in place.
+func (t *inotifyTree) addTree(dir string, onFile func(path string)) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable or vanished directories are skipped
+			return nil
+		}
+		if !entry.IsDir() {
+			if onFile != nil {
+				onFile(path)
+			}
+			return nil
+		}
+
+		wd, err := syscall.InotifyAddWatch(t.fd, path, inotifyMask)
+		if err == syscall.ENOSPC {
+			// fs.inotify.max_user_watches is exhausted; part of the tree
+			// would go unwatched for good
+			if onFile == nil {
+				return fmt.Errorf("too many directories to watch (raise fs.inotify.max_user_watches): %v", err)
+			}
+			t.onLost(true)
+			return filepath.SkipAll
+		}
+		if err != nil {
+			if path == dir && onFile == nil {
+				return os.NewSyscallError("inotify_add_watch", err)
+			}
+			return nil
+		}
+		t.dirs[int32(wd)] = path
+		return nil
+	})
+}
+
+// readEvents decodes inotify events until the file is closed or fails
+func (t *inotifyTree) readEvents(file *os.File, onChange func(path string)) {
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(event.Len)
+			offset = nameEnd
+
+			if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				t.onLost(false)
+				continue
+			}
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				delete(t.dirs, event.Wd)
+				continue
+			}
+
+			dir, ok := t.dirs[event.Wd]
+			if !ok || event.Len == 0 {
+				continue
+			}
+			name := string(buf[nameStart:nameEnd])
+			for len(name) > 0 && name[len(name)-1] == 0 {
+				name = name[:len(name)-1]
+			}
+			path := filepath.Join(dir, name)
+
+			if event.Mask&syscall.IN_ISDIR == 0 {
+				onChange(path)
+				continue
+			}
+
+			switch {
+			case event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+				// Watch the new directory and report what is already in it
+				t.addTree(path, onChange)
+			case event.Mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+				// Everything that was inside is gone
+				onChange(filepath.Join(path, "..."))
+			}
+		}
+	}
+}
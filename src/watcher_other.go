@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package main
+
+// watchTree is not implemented on this platform; scans always fall back to
+// reconcile or digest mode
+func watchTree(root string, onChange func(path string), onLost func(failed bool)) (func(), error) {
+	return nil, errWatcherUnsupported
+}
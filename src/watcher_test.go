@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useTestWatcher installs a primed watcher over root with the given touched
+// paths (relative to root) until the test ends
+func useTestWatcher(t *testing.T, root string, touched map[string]time.Time) *WorkspaceWatcher {
+	t.Helper()
+
+	watcher := &WorkspaceWatcher{
+		Root:    root,
+		Started: time.Now().Add(-time.Hour),
+		touched: make(map[string]time.Time),
+		primed:  []string{root},
+		stop:    func() {},
+	}
+	for name, touchedAt := range touched {
+		watcher.touched[filepath.Join(root, filepath.FromSlash(name))] = touchedAt
+	}
+
+	previous := activeWatcher
+	activeWatcher = watcher
+	t.Cleanup(func() { activeWatcher = previous })
+
+	return watcher
+}
+
+func TestWatcherScanSettlesTouchedSet(t *testing.T) {
+	root, fake := setupFakeWorkspace(t)
+	earlier := time.Now().Add(-time.Minute)
+	watcher := useTestWatcher(t, root, map[string]time.Time{
+		"Content/edited.txt":   earlier,
+		"Content/reverted.txt": earlier,
+		"Content/later.txt":    time.Now().Add(time.Hour), // touched while the scan ran
+	})
+	fake.On("-ztag -x - reconcile -n", FakeResponse{Stdout: reconcileRecords(root, map[string]string{"Content/edited.txt": "edit"})})
+
+	files, _, err := getModifiedFilesNotOpened(context.Background(), []string{root}, false)
+	if err != nil {
+		t.Fatalf("getModifiedFilesNotOpened() error = %v", err)
+	}
+	if got := scannedActions(root, files); fmt.Sprint(got) != "[edit Content/edited.txt]" {
+		t.Errorf("files = %v, want [edit Content/edited.txt]", got)
+	}
+	if len(fake.Calls) == 0 || fake.Calls[0].Stdin == "" {
+		t.Fatalf("calls = %+v, want a batched reconcile of the touched paths", fake.Calls)
+	}
+
+	paths, ok := watcher.candidates([]string{root}, true)
+	want := []string{filepath.Join(root, "Content", "edited.txt"), filepath.Join(root, "Content", "later.txt")}
+	if !ok || fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("touched after the scan = %v, want %v", paths, want)
+	}
+}
+
+func TestWatcherScanKeepsTouchedSetOnFailure(t *testing.T) {
+	root, fake := setupFakeWorkspace(t)
+	watcher := useTestWatcher(t, root, map[string]time.Time{"Content/a.txt": time.Now().Add(-time.Minute)})
+	fake.On("-ztag -x - reconcile -n", FakeResponse{Stderr: "Perforce client error:\n\tConnect to server failed; check $P4PORT.\n", Err: errors.New("exit status 1")})
+
+	if _, _, err := getModifiedFilesNotOpened(context.Background(), []string{root}, false); err == nil {
+		t.Fatal("getModifiedFilesNotOpened() error = nil, want the reconcile failure")
+	}
+	if paths, _ := watcher.candidates([]string{root}, true); len(paths) != 1 {
+		t.Errorf("touched after a failed scan = %v, want the path kept", paths)
+	}
+}
@@ -0,0 +1,104 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// changeNotifyMask is the set of changes that can mean a file's content changed
+const changeNotifyMask = syscall.FILE_NOTIFY_CHANGE_FILE_NAME | syscall.FILE_NOTIFY_CHANGE_DIR_NAME |
+	syscall.FILE_NOTIFY_CHANGE_ATTRIBUTES | syscall.FILE_NOTIFY_CHANGE_SIZE |
+	syscall.FILE_NOTIFY_CHANGE_LAST_WRITE
+
+// errorNotifyEnumDir is ERROR_NOTIFY_ENUM_DIR, returned when too many changes
+// happened to fit in the buffer
+const errorNotifyEnumDir = syscall.Errno(1022)
+
+// watchTree watches root recursively with ReadDirectoryChangesW. onChange gets
+// each touched path; onLost is called when events were dropped, with failed
+// set when watching stopped for good. The returned function stops watching.
+func watchTree(root string, onChange func(path string), onLost func(failed bool)) (func(), error) {
+	rootPtr, err := syscall.UTF16PtrFromString(root)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := syscall.CreateFile(rootPtr,
+		syscall.FILE_LIST_DIRECTORY,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil,
+		syscall.OPEN_EXISTING,
+		syscall.FILE_FLAG_BACKUP_SEMANTICS,
+		0)
+	if err != nil {
+		return nil, os.NewSyscallError("CreateFile", err)
+	}
+
+	var once sync.Once
+	stopped := make(chan struct{})
+	stop := func() {
+		once.Do(func() {
+			close(stopped)
+			// Unblock the pending ReadDirectoryChangesW, then release the handle
+			syscall.CancelIoEx(handle, nil)
+			syscall.CloseHandle(handle)
+		})
+	}
+
+	go readDirectoryChanges(handle, root, stopped, onChange, onLost)
+
+	return stop, nil
+}
+
+// readDirectoryChanges decodes change notifications until the watch is stopped
+func readDirectoryChanges(handle syscall.Handle, root string, stopped chan struct{}, onChange func(path string), onLost func(failed bool)) {
+	// FILE_NOTIFY_INFORMATION records must be DWORD aligned
+	buf := make([]uint32, 16*1024)
+	bufBytes := (*byte)(unsafe.Pointer(&buf[0]))
+	bufLen := uint32(len(buf) * 4)
+
+	for {
+		var n uint32
+		err := syscall.ReadDirectoryChanges(handle, bufBytes, bufLen, true, changeNotifyMask, &n, nil, 0)
+
+		select {
+		case <-stopped:
+			return
+		default:
+		}
+
+		if err == errorNotifyEnumDir || (err == nil && n == 0) {
+			// The buffer overflowed and changes were dropped
+			onLost(false)
+			continue
+		}
+		if err != nil {
+			onLost(true)
+			return
+		}
+
+		raw := unsafe.Slice((*byte)(unsafe.Pointer(&buf[0])), n)
+		for offset := uint32(0); offset < n; {
+			info := (*syscall.FileNotifyInformation)(unsafe.Pointer(&raw[offset]))
+			nameLen := info.FileNameLength / 2
+			name := syscall.UTF16ToString(unsafe.Slice(&info.FileName, nameLen))
+			path := filepath.Join(root, name)
+
+			onChange(path)
+			// Deleted or renamed-away entries may have been directories
+			if info.Action == syscall.FILE_ACTION_REMOVED || info.Action == syscall.FILE_ACTION_RENAMED_OLD_NAME {
+				onChange(filepath.Join(path, "..."))
+			}
+
+			if info.NextEntryOffset == 0 {
+				break
+			}
+			offset += info.NextEntryOffset
+		}
+	}
+}